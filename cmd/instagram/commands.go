@@ -0,0 +1,552 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+// env is the state shared by the commands.
+type env struct {
+	client *instagram.Client
+	out    *printer
+	limit  int
+	stdin  io.Reader
+	stderr io.Writer
+
+	// pending is the iterator of the last list, if it has more items.
+	pending lister
+
+	// interactive is set by the shell, the only user of pending.
+	interactive bool
+
+	// rate records the rate limits of the last response.
+	rate *rateTransport
+}
+
+// command is a subcommand of a service.
+type command struct {
+	service string
+	name    string
+	args    string
+	help    string
+	run     func(ctx context.Context, e *env, args []string) error
+}
+
+// lister abstracts the iterators of the instagram package.
+type lister interface {
+	Next(ctx context.Context) bool
+	Err() error
+	item() interface{}
+}
+
+type mediaLister struct{ *instagram.MediaIterator }
+
+func (l mediaLister) item() interface{} { return *l.Media() }
+
+type userLister struct{ *instagram.UserIterator }
+
+func (l userLister) item() interface{} { return *l.User() }
+
+// peekedLister is a lister advanced once to tell whether it has more items.
+type peekedLister struct {
+	lister
+	peeked bool
+}
+
+func (l *peekedLister) Next(ctx context.Context) bool {
+	if l.peeked {
+		l.peeked = false
+		return true
+	}
+	return l.lister.Next(ctx)
+}
+
+var commands = []*command{
+	{"users", "get", "[user-id]", "show a user, the authenticated one by default", usersGet},
+	{"users", "search", "<query>", "search users by name", usersSearch},
+	{"users", "feed", "", "list the media of the authenticated user's feed", usersFeed},
+	{"users", "recent", "[user-id]", "list the media published by a user", usersRecent},
+	{"users", "liked", "", "list the media liked by the authenticated user", usersLiked},
+
+	{"media", "get", "<media-id>", "show a media", mediaGet},
+	{"media", "shortcode", "<shortcode>", "show a media by the shortcode of its link", mediaShortcode},
+	{"media", "search", "-lat <lat> -lng <lng> [-distance <m>]", "search media taken around a point", mediaSearch},
+	{"media", "popular", "", "list popular media", mediaPopular},
+
+	{"tags", "get", "<tag>", "show a tag", tagsGet},
+	{"tags", "search", "<query>", "search tags by name", tagsSearch},
+	{"tags", "recent", "<tag>", "list the media recently tagged", tagsRecent},
+
+	{"locations", "get", "<location-id>", "show a location", locationsGet},
+	{"locations", "search", "-lat <lat> -lng <lng> [-distance <m>]", "search locations around a point", locationsSearch},
+	{"locations", "recent", "<location-id>", "list the recent media of a location", locationsRecent},
+
+	{"relationships", "follows", "[user-id]", "list the users a user follows", relationshipsFollows},
+	{"relationships", "followed-by", "[user-id]", "list the users following a user", relationshipsFollowedBy},
+	{"relationships", "requested-by", "", "list the users asking to follow the authenticated user", relationshipsRequestedBy},
+	{"relationships", "get", "<user-id>", "show the relationship with a user", relationshipAction("")},
+	{"relationships", "follow", "<user-id>", "follow a user", relationshipAction("follow")},
+	{"relationships", "unfollow", "<user-id>", "unfollow a user", relationshipAction("unfollow")},
+	{"relationships", "block", "<user-id>", "block a user", relationshipAction("block")},
+	{"relationships", "unblock", "<user-id>", "unblock a user", relationshipAction("unblock")},
+	{"relationships", "approve", "<user-id>", "approve a follow request", relationshipAction("approve")},
+	{"relationships", "deny", "<user-id>", "deny a follow request", relationshipAction("deny")},
+
+	{"comments", "list", "<media-id>", "list the comments on a media", commentsList},
+	{"comments", "add", "<media-id> <text>", "comment on a media", commentsAdd},
+	{"comments", "delete", "<media-id> <comment-id>", "delete a comment", commentsDelete},
+
+	{"likes", "list", "<media-id>", "list the users who liked a media", likesList},
+	{"likes", "add", "<media-id>", "like a media", likesAdd},
+	{"likes", "remove", "<media-id>", "unlike a media", likesRemove},
+
+	{"subscriptions", "list", "", "list the realtime subscriptions", subscriptionsList},
+	{"subscriptions", "add", "-object <object> [-object-id <id>] -callback <url> [-verify-token <token>] [-lat <lat> -lng <lng> -radius <m>]", "create a realtime subscription", subscriptionsAdd},
+	{"subscriptions", "delete", "<subscription-id>|all", "delete a realtime subscription, or all of them", subscriptionsDelete},
+}
+
+func printCommands(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s %s %s\t%s\n", c.service, c.name, c.args, c.help)
+	}
+	tw.Flush()
+}
+
+// exec runs the command named by the first two args.
+func (e *env) exec(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("missing command; run \"instagram help\"")
+	}
+	for _, c := range commands {
+		if c.service == args[0] && c.name == args[1] {
+			return c.run(ctx, e, args[2:])
+		}
+	}
+	return fmt.Errorf("unknown command %q; run \"instagram help\"", strings.Join(args[:2], " "))
+}
+
+// list prints up to e.limit items of l. In the shell, l is kept for the next
+// command if it has more.
+func (e *env) list(ctx context.Context, l lister) error {
+	e.pending = nil
+
+	items := []interface{}{}
+	for e.limit == 0 || len(items) < e.limit {
+		if !l.Next(ctx) {
+			if err := l.Err(); err != nil {
+				return err
+			}
+			return e.out.print(items)
+		}
+		items = append(items, l.item())
+	}
+
+	if err := e.out.print(items); err != nil {
+		return err
+	}
+	if !e.interactive {
+		return nil
+	}
+	// Look ahead, so that next is only offered when there are more items.
+	if !l.Next(ctx) {
+		return l.Err()
+	}
+	p, ok := l.(*peekedLister)
+	if !ok {
+		p = &peekedLister{lister: l}
+	}
+	p.peeked = true
+	e.pending = p
+	return nil
+}
+
+// show prints v, which is not paginated.
+func (e *env) show(v interface{}) error {
+	e.pending = nil
+	return e.out.print(v)
+}
+
+// userArg returns the optional user ID argument of args.
+func userArg(args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		return "self", nil
+	case 1:
+		return args[0], nil
+	}
+	return "", fmt.Errorf("too many arguments")
+}
+
+// oneArg returns the single argument of args.
+func oneArg(args []string, name string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected a single %s argument", name)
+	}
+	return args[0], nil
+}
+
+func noArgs(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("unexpected arguments %q", args)
+	}
+	return nil
+}
+
+// area parses the -lat, -lng and -distance flags of args.
+func area(args []string) (*instagram.Parameters, error) {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	lat := fs.Float64("lat", 0, "")
+	lng := fs.Float64("lng", 0, "")
+	distance := fs.Float64("distance", 0, "")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := noArgs(fs.Args()); err != nil {
+		return nil, err
+	}
+	if *lat == 0 && *lng == 0 {
+		return nil, fmt.Errorf("-lat and -lng are required")
+	}
+	return &instagram.Parameters{Lat: *lat, Lng: *lng, Distance: *distance}, nil
+}
+
+func usersGet(ctx context.Context, e *env, args []string) error {
+	id, err := userArg(args)
+	if err != nil {
+		return err
+	}
+	user, _, err := e.client.Users.GetContext(ctx, id)
+	if err != nil {
+		return err
+	}
+	return e.show(user)
+}
+
+func usersSearch(ctx context.Context, e *env, args []string) error {
+	q, err := oneArg(args, "query")
+	if err != nil {
+		return err
+	}
+	users, _, err := e.client.Users.SearchContext(ctx, q, &instagram.Parameters{Count: uint64(e.limit)})
+	if err != nil {
+		return err
+	}
+	return e.show(users)
+}
+
+func usersFeed(ctx context.Context, e *env, args []string) error {
+	if err := noArgs(args); err != nil {
+		return err
+	}
+	return e.list(ctx, mediaLister{e.client.Users.MediaFeedIterator(nil)})
+}
+
+func usersRecent(ctx context.Context, e *env, args []string) error {
+	id, err := userArg(args)
+	if err != nil {
+		return err
+	}
+	return e.list(ctx, mediaLister{e.client.Users.RecentMediaIterator(id, nil)})
+}
+
+func usersLiked(ctx context.Context, e *env, args []string) error {
+	if err := noArgs(args); err != nil {
+		return err
+	}
+	return e.list(ctx, mediaLister{e.client.Users.LikedMediaIterator(nil)})
+}
+
+func mediaGet(ctx context.Context, e *env, args []string) error {
+	id, err := oneArg(args, "media ID")
+	if err != nil {
+		return err
+	}
+	media, _, err := e.client.Media.GetContext(ctx, id)
+	if err != nil {
+		return err
+	}
+	return e.show(media)
+}
+
+func mediaShortcode(ctx context.Context, e *env, args []string) error {
+	code, err := oneArg(args, "shortcode")
+	if err != nil {
+		return err
+	}
+	media, _, err := e.client.Media.GetShortcodeContext(ctx, code)
+	if err != nil {
+		return err
+	}
+	return e.show(media)
+}
+
+func mediaSearch(ctx context.Context, e *env, args []string) error {
+	opt, err := area(args)
+	if err != nil {
+		return err
+	}
+	if e.limit > 0 {
+		opt.Count = uint64(e.limit)
+	}
+	media, _, err := e.client.Media.SearchContext(ctx, opt)
+	if err != nil {
+		return err
+	}
+	return e.show(media)
+}
+
+func mediaPopular(ctx context.Context, e *env, args []string) error {
+	if err := noArgs(args); err != nil {
+		return err
+	}
+	media, _, err := e.client.Media.PopularContext(ctx)
+	if err != nil {
+		return err
+	}
+	return e.show(media)
+}
+
+func tagsGet(ctx context.Context, e *env, args []string) error {
+	name, err := oneArg(args, "tag")
+	if err != nil {
+		return err
+	}
+	tag, _, err := e.client.Tags.GetContext(ctx, name)
+	if err != nil {
+		return err
+	}
+	return e.show(tag)
+}
+
+func tagsSearch(ctx context.Context, e *env, args []string) error {
+	q, err := oneArg(args, "query")
+	if err != nil {
+		return err
+	}
+	tags, _, err := e.client.Tags.SearchContext(ctx, q)
+	if err != nil {
+		return err
+	}
+	return e.show(tags)
+}
+
+func tagsRecent(ctx context.Context, e *env, args []string) error {
+	name, err := oneArg(args, "tag")
+	if err != nil {
+		return err
+	}
+	return e.list(ctx, mediaLister{e.client.Tags.RecentMediaIterator(name, nil)})
+}
+
+func locationsGet(ctx context.Context, e *env, args []string) error {
+	id, err := oneArg(args, "location ID")
+	if err != nil {
+		return err
+	}
+	location, _, err := e.client.Locations.GetContext(ctx, id)
+	if err != nil {
+		return err
+	}
+	return e.show(location)
+}
+
+func locationsSearch(ctx context.Context, e *env, args []string) error {
+	opt, err := area(args)
+	if err != nil {
+		return err
+	}
+	locations, _, err := e.client.Locations.SearchContext(ctx, opt.Lat, opt.Lng, opt)
+	if err != nil {
+		return err
+	}
+	return e.show(locations)
+}
+
+func locationsRecent(ctx context.Context, e *env, args []string) error {
+	id, err := oneArg(args, "location ID")
+	if err != nil {
+		return err
+	}
+	return e.list(ctx, mediaLister{e.client.Locations.RecentMediaIterator(id, nil)})
+}
+
+func relationshipsFollows(ctx context.Context, e *env, args []string) error {
+	id, err := userArg(args)
+	if err != nil {
+		return err
+	}
+	return e.list(ctx, userLister{e.client.Relationships.FollowsIterator(id, nil)})
+}
+
+func relationshipsFollowedBy(ctx context.Context, e *env, args []string) error {
+	id, err := userArg(args)
+	if err != nil {
+		return err
+	}
+	return e.list(ctx, userLister{e.client.Relationships.FollowedByIterator(id, nil)})
+}
+
+func relationshipsRequestedBy(ctx context.Context, e *env, args []string) error {
+	if err := noArgs(args); err != nil {
+		return err
+	}
+	users, _, err := e.client.Relationships.RequestedByContext(ctx)
+	if err != nil {
+		return err
+	}
+	return e.show(users)
+}
+
+// relationshipAction returns the command carrying out action with a user, or
+// showing the relationship if action is empty.
+func relationshipAction(action string) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		id, err := oneArg(args, "user ID")
+		if err != nil {
+			return err
+		}
+
+		r := e.client.Relationships
+		var rel *instagram.Relationship
+		switch action {
+		case "":
+			rel, _, err = r.RelationshipContext(ctx, id)
+		case "follow":
+			rel, _, err = r.FollowContext(ctx, id)
+		case "unfollow":
+			rel, _, err = r.UnfollowContext(ctx, id)
+		case "block":
+			rel, _, err = r.BlockContext(ctx, id)
+		case "unblock":
+			rel, _, err = r.UnblockContext(ctx, id)
+		case "approve":
+			rel, _, err = r.ApproveContext(ctx, id)
+		case "deny":
+			rel, _, err = r.DenyContext(ctx, id)
+		}
+		if err != nil {
+			return err
+		}
+		return e.show(rel)
+	}
+}
+
+func commentsList(ctx context.Context, e *env, args []string) error {
+	id, err := oneArg(args, "media ID")
+	if err != nil {
+		return err
+	}
+	comments, _, err := e.client.Comments.MediaCommentsContext(ctx, id)
+	if err != nil {
+		return err
+	}
+	return e.show(comments)
+}
+
+func commentsAdd(ctx context.Context, e *env, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("expected a media ID and a text")
+	}
+	_, err := e.client.Comments.AddContext(ctx, args[0], []string{strings.Join(args[1:], " ")})
+	return err
+}
+
+func commentsDelete(ctx context.Context, e *env, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected a media ID and a comment ID")
+	}
+	_, err := e.client.Comments.DeleteContext(ctx, args[0], args[1])
+	return err
+}
+
+func likesList(ctx context.Context, e *env, args []string) error {
+	id, err := oneArg(args, "media ID")
+	if err != nil {
+		return err
+	}
+	users, _, err := e.client.Likes.MediaLikesContext(ctx, id)
+	if err != nil {
+		return err
+	}
+	return e.show(users)
+}
+
+func likesAdd(ctx context.Context, e *env, args []string) error {
+	id, err := oneArg(args, "media ID")
+	if err != nil {
+		return err
+	}
+	_, err = e.client.Likes.LikeContext(ctx, id)
+	return err
+}
+
+func likesRemove(ctx context.Context, e *env, args []string) error {
+	id, err := oneArg(args, "media ID")
+	if err != nil {
+		return err
+	}
+	_, err = e.client.Likes.UnlikeContext(ctx, id)
+	return err
+}
+
+func subscriptionsList(ctx context.Context, e *env, args []string) error {
+	if err := noArgs(args); err != nil {
+		return err
+	}
+	subs, _, err := e.client.Realtime.ListSubscriptionsContext(ctx)
+	if err != nil {
+		return err
+	}
+	return e.show(subs)
+}
+
+func subscriptionsAdd(ctx context.Context, e *env, args []string) error {
+	sr := new(instagram.SubscriptionRequest)
+	fs := flag.NewFlagSet("subscriptions add", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&sr.Object, "object", "", "")
+	fs.StringVar(&sr.ObjectID, "object-id", "", "")
+	fs.StringVar(&sr.CallbackURL, "callback", "", "")
+	fs.StringVar(&sr.VerifyToken, "verify-token", "", "")
+	fs.Float64Var(&sr.Lat, "lat", 0, "")
+	fs.Float64Var(&sr.Lng, "lng", 0, "")
+	fs.IntVar(&sr.Radius, "radius", 0, "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := noArgs(fs.Args()); err != nil {
+		return err
+	}
+
+	sub, _, err := e.client.Realtime.SubscribeContext(ctx, sr)
+	if err != nil {
+		return err
+	}
+	return e.show(sub)
+}
+
+func subscriptionsDelete(ctx context.Context, e *env, args []string) error {
+	id, err := oneArg(args, "subscription ID")
+	if err != nil {
+		return err
+	}
+	if id == "all" {
+		_, err = e.client.Realtime.DeleteAllSubscriptionsContext(ctx)
+	} else {
+		_, err = e.client.Realtime.UnsubscribeFromContext(ctx, id)
+	}
+	return err
+}
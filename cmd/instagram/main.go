@@ -0,0 +1,119 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command instagram is a command-line client for the Instagram API.
+//
+// Usage:
+//
+//	instagram [flags] <service> <command> [arguments]
+//
+// For example:
+//
+//	instagram users get self
+//	instagram -format table -limit 100 tags recent nofilter
+//	instagram media search -lat 48.858 -lng 2.294
+//
+// Credentials are read from the -client-id, -client-secret and -access-token
+// flags, or else from the INSTAGRAM_CLIENT_ID, INSTAGRAM_CLIENT_SECRET and
+// INSTAGRAM_ACCESS_TOKEN environment variables. Paginated lists are fetched
+// page after page until -limit items have been printed. Results are printed
+// as JSON, JSON Lines or tables, according to -format.
+//
+// Run "instagram help" for the list of commands.
+//
+// "instagram shell" starts an interactive shell running the same commands
+// with a single session. There, "next" prints the next items of the last
+// list, the remaining rate limit is shown after each command, and results
+// are saved to a file by ending a command with "> file" or ">> file".
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+// errUsage is returned for invalid command lines, after printing the usage.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv)
+	if err == errUsage {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "instagram: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command line args.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) error {
+	fs := flag.NewFlagSet("instagram", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { usage(stderr, fs) }
+
+	clientID := fs.String("client-id", getenv("INSTAGRAM_CLIENT_ID"), "client ID `id` ($INSTAGRAM_CLIENT_ID)")
+	clientSecret := fs.String("client-secret", getenv("INSTAGRAM_CLIENT_SECRET"), "client `secret` ($INSTAGRAM_CLIENT_SECRET)")
+	accessToken := fs.String("access-token", getenv("INSTAGRAM_ACCESS_TOKEN"), "access `token` ($INSTAGRAM_ACCESS_TOKEN)")
+	baseURL := fs.String("base-url", instagram.BaseURL, "API base `url`")
+	format := fs.String("format", "json", "output `format`: json, jsonl or table")
+	limit := fs.Int("limit", 20, "maximum number of items of lists, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return errUsage
+	}
+
+	rate := &rateTransport{}
+	client := instagram.NewClient(&http.Client{Transport: rate})
+	client.ClientID = *clientID
+	client.ClientSecret = *clientSecret
+	client.AccessToken = *accessToken
+	u, err := url.Parse(*baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid -base-url: %v", err)
+	}
+	client.BaseURL = u
+
+	out, err := newPrinter(stdout, *format)
+	if err != nil {
+		return err
+	}
+
+	e := &env{client: client, out: out, limit: *limit, stdin: stdin, stderr: stderr, rate: rate}
+	switch {
+	case fs.NArg() == 0 || fs.Arg(0) == "help":
+		usage(stdout, fs)
+		return nil
+	case fs.Arg(0) == "shell":
+		if fs.NArg() > 1 {
+			return fmt.Errorf("unexpected arguments %q", fs.Args()[1:])
+		}
+		return e.shell(ctx)
+	}
+	return e.exec(ctx, fs.Args())
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: instagram [flags] <service> <command> [arguments]\n")
+	fmt.Fprintf(w, "       instagram [flags] shell\n\nFlags:\n")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fmt.Fprintf(w, "\nCommands:\n")
+	printCommands(w)
+}
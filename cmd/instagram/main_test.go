@@ -0,0 +1,167 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/carbocation/go-instagram/instagram"
+	"github.com/carbocation/go-instagram/instagram/instagramtest"
+)
+
+func newTestServer() *instagramtest.Server {
+	s := instagramtest.NewServer()
+	s.AddUser(instagram.User{ID: "1", Username: "alice", FullName: "Alice"}, "alice-token")
+	s.AddUser(instagram.User{ID: "2", Username: "bob", FullName: "Bob"}, "bob-token")
+	for i := 0; i < 30; i++ {
+		s.AddMedia(instagram.Media{User: &instagram.User{ID: "1"}, Tags: []string{"go"}})
+	}
+	return s
+}
+
+// runCommand runs the command line args against s as alice.
+func runCommand(t *testing.T, s *instagramtest.Server, args ...string) (string, error) {
+	env := map[string]string{"INSTAGRAM_ACCESS_TOKEN": "alice-token"}
+	args = append([]string{"-base-url", s.URL + "/v1/"}, args...)
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), args, strings.NewReader(""), &stdout, &stderr, func(k string) string { return env[k] })
+	return stdout.String(), err
+}
+
+func TestRun_json(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	out, err := runCommand(t, s, "users", "get", "2")
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	var user instagram.User
+	if err := json.Unmarshal([]byte(out), &user); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if user.Username != "bob" {
+		t.Errorf("users get 2 printed %+v, want bob", user)
+	}
+}
+
+func TestRun_jsonlPaging(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	out, err := runCommand(t, s, "-format", "jsonl", "-limit", "25", "tags", "recent", "go")
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 25 {
+		t.Fatalf("tags recent printed %d lines, want 25 over two pages", len(lines))
+	}
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		var m instagram.Media
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("line is not JSON: %v\n%s", err, line)
+		}
+		if seen[m.ID] {
+			t.Errorf("media %s printed twice", m.ID)
+		}
+		seen[m.ID] = true
+	}
+
+	out, err = runCommand(t, s, "-format", "jsonl", "-limit", "0", "users", "recent")
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if n := strings.Count(out, "\n"); n != 30 {
+		t.Errorf("users recent with no limit printed %d lines, want 30", n)
+	}
+}
+
+func TestRun_limitNoLookAhead(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	if _, err := runCommand(t, s, "-limit", "20", "tags", "recent", "go"); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	_, resp, err := s.Client("alice-token").Users.GetContext(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("GetContext returned error: %v", err)
+	}
+	rl, _ := resp.GetRatelimit()
+	if used := rl.Limit - rl.Remaining; used != 2 {
+		t.Errorf("a limited list and a get used %d calls, want 2", used)
+	}
+}
+
+func TestRun_table(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	out, err := runCommand(t, s, "-format", "table", "users", "search", "bo")
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "bob") {
+		t.Errorf("users search printed:\n%s", out)
+	}
+}
+
+func TestRun_actions(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	out, err := runCommand(t, s, "relationships", "follow", "2")
+	if err != nil {
+		t.Fatalf("relationships follow returned error: %v", err)
+	}
+	if !strings.Contains(out, `"outgoing_status": "follows"`) {
+		t.Errorf("relationships follow printed:\n%s", out)
+	}
+
+	out, err = runCommand(t, s, "-format", "jsonl", "relationships", "follows")
+	if err != nil {
+		t.Fatalf("relationships follows returned error: %v", err)
+	}
+	if !strings.Contains(out, `"username":"bob"`) {
+		t.Errorf("relationships follows printed:\n%s", out)
+	}
+}
+
+func TestRun_errors(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	for _, args := range [][]string{
+		{"users", "frobnicate"},
+		{"users"},
+		{"media", "get"},
+		{"media", "search", "-distance", "10"},
+		{"-format", "xml", "users", "get"},
+		{"media", "get", "nope"},
+	} {
+		if _, err := runCommand(t, s, args...); err == nil {
+			t.Errorf("run(%q) returned no error", args)
+		}
+	}
+}
+
+func TestRun_help(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := run(context.Background(), []string{"help"}, nil, &stdout, &stdout, func(string) string { return "" }); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "tags recent <tag>") {
+		t.Errorf("help does not list the commands:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+// printer prints results in one of the output formats.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case "json", "jsonl", "table":
+		return &printer{w: w, format: format}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, want json, jsonl or table", format)
+}
+
+// print prints v, a value or a slice of values of the instagram package.
+func (p *printer) print(v interface{}) error {
+	switch p.format {
+	case "jsonl":
+		enc := json.NewEncoder(p.w)
+		for _, item := range items(v) {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "table":
+		return p.table(items(v))
+	}
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// items returns the elements of v if it is a slice, or else v alone.
+func items(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		if v == nil || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+			return nil
+		}
+		return []interface{}{v}
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items
+}
+
+// table prints items as a table whose columns depend on their type.
+func (p *printer) table(items []interface{}) error {
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	var header []string
+	for _, item := range items {
+		h, row := columns(item)
+		if header == nil {
+			header = h
+			fmt.Fprintln(tw, strings.Join(header, "\t"))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// columns returns the column names and values of item in tables.
+func columns(item interface{}) (header, row []string) {
+	if rv := reflect.ValueOf(item); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		item = rv.Elem().Interface()
+	}
+
+	switch v := item.(type) {
+	case instagram.Media:
+		var caption string
+		if v.Caption != nil {
+			caption = v.Caption.Text
+		}
+		var likes, comments int
+		if v.Likes != nil {
+			likes = v.Likes.Count
+		}
+		if v.Comments != nil {
+			comments = v.Comments.Count
+		}
+		return []string{"ID", "USER", "CREATED", "LIKES", "COMMENTS", "LINK", "CAPTION"},
+			[]string{v.ID, username(v.User), timestamp(v.CreatedTime), strconv.Itoa(likes), strconv.Itoa(comments), v.Link, oneLine(caption)}
+
+	case instagram.User:
+		var media, follows, followedBy string
+		if v.Counts != nil {
+			media = strconv.Itoa(v.Counts.Media)
+			follows = strconv.Itoa(v.Counts.Follows)
+			followedBy = strconv.Itoa(v.Counts.FollowedBy)
+		}
+		return []string{"ID", "USERNAME", "FULL NAME", "MEDIA", "FOLLOWS", "FOLLOWED BY"},
+			[]string{v.ID, v.Username, v.FullName, media, follows, followedBy}
+
+	case instagram.Tag:
+		return []string{"NAME", "MEDIA"}, []string{v.Name, strconv.Itoa(v.MediaCount)}
+
+	case instagram.Location:
+		return []string{"ID", "NAME", "LATITUDE", "LONGITUDE"},
+			[]string{v.ID, v.Name, strconv.FormatFloat(v.Latitude, 'f', -1, 64), strconv.FormatFloat(v.Longitude, 'f', -1, 64)}
+
+	case instagram.Comment:
+		return []string{"ID", "FROM", "CREATED", "TEXT"},
+			[]string{v.ID, username(v.From), timestamp(v.CreatedTime), oneLine(v.Text)}
+
+	case instagram.Realtime:
+		return []string{"ID", "OBJECT", "OBJECT ID", "ASPECT", "CALLBACK"},
+			[]string{v.ID, v.Object, v.ObjectID, v.Aspect, v.CallbackURL}
+
+	case instagram.Relationship:
+		return []string{"OUTGOING", "INCOMING", "PRIVATE"},
+			[]string{v.OutgoingStatus, v.IncomingStatus, strconv.FormatBool(v.TargetUserIsPrivate)}
+	}
+
+	data, _ := json.Marshal(item)
+	return []string{"VALUE"}, []string{string(data)}
+}
+
+func username(u *instagram.User) string {
+	if u == nil {
+		return ""
+	}
+	return u.Username
+}
+
+func timestamp(t int64) string {
+	if t == 0 {
+		return ""
+	}
+	return time.Unix(t, 0).UTC().Format("2006-01-02 15:04")
+}
+
+// oneLine keeps tables aligned when s spans several lines or has tabs.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,218 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+// rateTransport records the rate limits of the responses it receives.
+type rateTransport struct {
+	// Transport sends the requests. http.DefaultTransport is used when nil.
+	Transport http.RoundTripper
+
+	mu   sync.Mutex
+	last instagram.Ratelimit
+	ok   bool
+}
+
+func (t *rateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if rl, err := (&instagram.Response{Response: resp}).GetRatelimit(); err == nil {
+		t.mu.Lock()
+		t.last, t.ok = rl, true
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// Ratelimit returns the rate limits of the last response carrying them.
+func (t *rateTransport) Ratelimit() (instagram.Ratelimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.last, t.ok
+}
+
+const shellHelp = `Commands:
+  <service> <command> [arguments]  run a command, as listed by "instagram help"
+  next                             print the next items of the last list
+  format json|jsonl|table          change the output format
+  limit <n>                        change the number of items printed by lists
+  help                             print this help
+  quit                             leave the shell
+
+End a command with "> file" to save its result to file, or ">> file" to
+append it.
+`
+
+// errQuit ends the shell.
+var errQuit = errors.New("quit")
+
+// shell reads commands from e.stdin and runs them until the end of the input.
+// Prompts, rate limits and errors are written to e.stderr.
+func (e *env) shell(ctx context.Context) error {
+	e.interactive = true
+	stdout := e.out.w
+	scanner := bufio.NewScanner(e.stdin)
+	for {
+		fmt.Fprint(e.stderr, "instagram> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(e.stderr)
+			return scanner.Err()
+		}
+
+		err := e.shellLine(ctx, scanner.Text())
+		e.out.w = stdout
+		if err == errQuit {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(e.stderr, "error: %v\n", err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// shellLine runs a command line of the shell.
+func (e *env) shellLine(ctx context.Context, line string) error {
+	args, err := splitLine(line)
+	if err != nil {
+		return err
+	}
+	args, redirect, appending, err := redirection(args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	if redirect != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if appending {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(redirect, flags, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		e.out.w = f
+	}
+
+	switch args[0] {
+	case "quit", "exit":
+		return errQuit
+	case "help":
+		fmt.Fprint(e.out.w, shellHelp)
+		return nil
+	case "format":
+		if len(args) != 2 {
+			return fmt.Errorf("expected a format")
+		}
+		out, err := newPrinter(e.out.w, args[1])
+		if err != nil {
+			return err
+		}
+		e.out.format = out.format
+		return nil
+	case "limit":
+		if len(args) != 2 {
+			return fmt.Errorf("expected a limit")
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid limit %q", args[1])
+		}
+		e.limit = n
+		return nil
+	case "next":
+		if len(args) != 1 {
+			return fmt.Errorf("unexpected arguments %q", args[1:])
+		}
+		if e.pending == nil {
+			return fmt.Errorf("no list to continue")
+		}
+		err = e.list(ctx, e.pending)
+	default:
+		err = e.exec(ctx, args)
+	}
+
+	if rl, ok := e.rate.Ratelimit(); ok {
+		fmt.Fprintf(e.stderr, "rate limit: %d/%d remaining\n", rl.Remaining, rl.Limit)
+	}
+	return err
+}
+
+// redirection splits the trailing "> file" or ">> file" off args.
+func redirection(args []string) (rest []string, file string, appending bool, err error) {
+	for i, arg := range args {
+		if arg != ">" && arg != ">>" {
+			continue
+		}
+		if i != len(args)-2 {
+			return nil, "", false, fmt.Errorf("%s must be followed by a single file name", arg)
+		}
+		return args[:i], args[i+1], arg == ">>", nil
+	}
+	return args, "", false, nil
+}
+
+// splitLine splits line into words separated by spaces. Single or double
+// quotes keep spaces within a word.
+func splitLine(line string) ([]string, error) {
+	var (
+		words []string
+		word  strings.Builder
+		quote rune
+		in    bool
+	)
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, in = r, true
+		case r == ' ' || r == '\t':
+			if in {
+				words = append(words, word.String())
+				word.Reset()
+				in = false
+			}
+		default:
+			word.WriteRune(r)
+			in = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote %c", quote)
+	}
+	if in {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
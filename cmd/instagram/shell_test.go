@@ -0,0 +1,132 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/carbocation/go-instagram/instagram/instagramtest"
+)
+
+func TestShell(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	saved := filepath.Join(t.TempDir(), "media.jsonl")
+	script := strings.Join([]string{
+		"format jsonl",
+		"limit 12",
+		"tags recent go",
+		"next",
+		"next > " + saved,
+		"next",
+		"users frobnicate",
+		"quit",
+		"users get self",
+	}, "\n")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-base-url", s.URL + "/v1/", "-access-token", "alice-token", "shell"}
+	if err := run(context.Background(), args, strings.NewReader(script), &stdout, &stderr, func(string) string { return "" }); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	if n := strings.Count(stdout.String(), "\n"); n != 24 {
+		t.Errorf("shell printed %d lines, want 12 for the list and 12 for next:\n%s", n, stdout.String())
+	}
+	data, err := ioutil.ReadFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 6 {
+		t.Errorf("saved %d lines, want the last 6 media", n)
+	}
+
+	errs := stderr.String()
+	for _, want := range []string{
+		"rate limit: 4998/5000 remaining",
+		"error: no list to continue",
+		`error: unknown command "users frobnicate"`,
+	} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("shell stderr does not contain %q:\n%s", want, errs)
+		}
+	}
+	if strings.Contains(errs, "4997/5000") {
+		t.Errorf("shell ran commands after quit:\n%s", errs)
+	}
+}
+
+func TestShell_exhausted(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	script := "limit 15\nformat jsonl\ntags recent go\nnext\nnext\nformat json\ntags recent none\n"
+	var stdout, stderr bytes.Buffer
+	args := []string{"-base-url", s.URL + "/v1/", "-access-token", "alice-token", "shell"}
+	if err := run(context.Background(), args, strings.NewReader(script), &stdout, &stderr, func(string) string { return "" }); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 31 || lines[30] != "[]" {
+		t.Errorf("shell printed %d lines, want 30 media and an empty list:\n%s", len(lines), stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "error: no list to continue") {
+		t.Errorf("next after the last item did not fail:\n%s", stderr.String())
+	}
+}
+
+func TestShell_lookAheadError(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	s.Inject("GET", "tags/*/media/recent", instagramtest.Slow(0), instagramtest.MetaError(400, "APIError", "boom"))
+
+	script := "limit 20\nformat jsonl\ntags recent go\n"
+	var stdout, stderr bytes.Buffer
+	args := []string{"-base-url", s.URL + "/v1/", "-access-token", "alice-token", "shell"}
+	if err := run(context.Background(), args, strings.NewReader(script), &stdout, &stderr, func(string) string { return "" }); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	if n := strings.Count(stdout.String(), "\n"); n != 20 {
+		t.Errorf("shell printed %d lines, want the 20 media fetched before the error:\n%s", n, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "boom") {
+		t.Errorf("shell stderr does not report the look-ahead error:\n%s", stderr.String())
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	got, err := splitLine(`comments add 1_1  "nice  shot" 'it''s' > out.json`)
+	if err != nil {
+		t.Fatalf("splitLine returned error: %v", err)
+	}
+	want := []string{"comments", "add", "1_1", "nice  shot", "its", ">", "out.json"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitLine returned %q, want %q", got, want)
+	}
+
+	if _, err := splitLine(`comments add "oops`); err == nil {
+		t.Errorf("splitLine with an unterminated quote returned no error")
+	}
+}
+
+func TestRedirection(t *testing.T) {
+	args, file, appending, err := redirection([]string{"users", "get", ">>", "u.json"})
+	if err != nil || file != "u.json" || !appending || !reflect.DeepEqual(args, []string{"users", "get"}) {
+		t.Errorf("redirection returned %q, %q, %v, %v", args, file, appending, err)
+	}
+	if _, _, _, err := redirection([]string{"users", ">", "a", "b"}); err == nil {
+		t.Errorf("redirection with two files returned no error")
+	}
+}
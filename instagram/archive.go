@@ -0,0 +1,336 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestFile is the name of the manifest in archive directories.
+const ManifestFile = "manifest.json"
+
+// manifestBatch is the number of media archived between two writes of the
+// manifest.
+const manifestBatch = 50
+
+// errNotArchived is the Error of the media whose comments and likes were not
+// fetched yet.
+const errNotArchived = "not archived yet"
+
+// ArchiveManifest is the content of an account archive, stored as JSON in
+// its ManifestFile. Media are ordered from the newest to the oldest, users in
+// the order they were first archived.
+type ArchiveManifest struct {
+	// User is the profile of the account, as of the last run.
+	User *User `json:"user"`
+
+	// UpdatedTime is the Unix time of the last run.
+	UpdatedTime int64 `json:"updated_time"`
+
+	Media      []*ArchivedMedia `json:"media"`
+	Follows    []User           `json:"follows"`
+	FollowedBy []User           `json:"followed_by"`
+	LikedMedia []Media          `json:"liked_media"`
+}
+
+// ArchivedMedia is a media published by the account, with its comments and
+// likers.
+type ArchivedMedia struct {
+	Media    Media     `json:"media"`
+	Comments []Comment `json:"comments"`
+	Likes    []User    `json:"likes"`
+
+	// File is the path of the downloaded image or video, relative to the
+	// archive directory. It is empty if the download failed.
+	File string `json:"file,omitempty"`
+
+	// Error is the error fetching the comments or likes of the media, if
+	// any, or "not archived yet" if the run adding the media was interrupted
+	// before fetching them. They are fetched again by the next run.
+	Error string `json:"error,omitempty"`
+}
+
+// ArchiveStats counts the items added to an archive by a run.
+type ArchiveStats struct {
+	Media      int
+	Follows    int
+	FollowedBy int
+	LikedMedia int
+	Files      int
+}
+
+// Archiver backs up an account into a directory: its profile, every media it
+// published with their comments and likers, the users it follows and is
+// followed by, and the media it liked, in a JSON manifest, along with the
+// files of its media in a "media" subdirectory:
+//
+//	a := &instagram.Archiver{Client: client, Dir: "backup"}
+//	stats, err := a.Archive(ctx)
+//
+// Running it again on the same directory only adds the new items: media more
+// recent than the archived ones, and users not archived yet. Users are never
+// removed, so that the archive keeps former followers. Failed file downloads
+// and comments or likes are retried by the next run.
+//
+// The manifest is written regularly while comments and likes are fetched, so
+// that an interrupted run loses little work and the next run picks up where
+// it stopped.
+type Archiver struct {
+	Client *Client
+
+	// Dir is the archive directory. It is created if needed.
+	Dir string
+
+	// UserID is the account to archive, the authenticated user if empty.
+	// Liked media are only archived for the authenticated user.
+	UserID string
+
+	// Downloader downloads the files of the media. Its Dir is ignored. A
+	// Downloader with the default settings is used when nil.
+	Downloader *Downloader
+
+	// OnProgress, if set, is called with a description of each step.
+	OnProgress func(step string)
+}
+
+// Archive runs the archiver and returns what it added.
+func (a *Archiver) Archive(ctx context.Context) (*ArchiveStats, error) {
+	userID := a.UserID
+	if userID == "" {
+		userID = "self"
+	}
+	if err := os.MkdirAll(filepath.Join(a.Dir, "media"), 0755); err != nil {
+		return nil, err
+	}
+	m, err := ReadArchiveManifest(a.Dir)
+	if os.IsNotExist(err) {
+		m, err = new(ArchiveManifest), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stats := new(ArchiveStats)
+
+	a.progress("profile")
+	user, _, err := a.Client.Users.GetContext(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("instagram: archiving profile: %w", err)
+	}
+	m.User = user
+
+	a.progress("media")
+	known := make(map[string]bool)
+	for _, am := range m.Media {
+		known[am.Media.ID] = true
+	}
+	media, err := collectMedia(ctx, a.Client.Users.RecentMediaIterator(userID, nil), known)
+	if err != nil {
+		return nil, fmt.Errorf("instagram: archiving media: %w", err)
+	}
+	// The new media are added at once, marked as not archived yet, so that
+	// an interrupted run leaves them to the next one like failed media.
+	added := make([]*ArchivedMedia, len(media), len(media)+len(m.Media))
+	for i := range media {
+		added[i] = &ArchivedMedia{Media: media[i], Error: errNotArchived}
+	}
+	m.Media = append(added, m.Media...)
+	stats.Media = len(added)
+
+	var failed []string
+	var failure error
+	pending := 0
+	// Media are archived from the oldest.
+	for i := len(m.Media) - 1; i >= 0; i-- {
+		am := m.Media[i]
+		if am.Error == "" {
+			continue
+		}
+		a.progress("comments and likes of media " + am.Media.ID)
+		if err := a.archiveMedia(ctx, am); err != nil {
+			if ctx.Err() != nil {
+				if werr := writeArchiveManifest(a.Dir, m); werr != nil {
+					return nil, werr
+				}
+				return nil, ctx.Err()
+			}
+			failed = append(failed, am.Media.ID)
+			if failure == nil {
+				failure = err
+			}
+		}
+		if pending++; pending == manifestBatch {
+			if err := writeArchiveManifest(a.Dir, m); err != nil {
+				return nil, err
+			}
+			pending = 0
+		}
+	}
+	if err := writeArchiveManifest(a.Dir, m); err != nil {
+		return nil, err
+	}
+
+	a.progress("follows")
+	if m.Follows, stats.Follows, err = mergeUsers(ctx, m.Follows, a.Client.Relationships.FollowsIterator(userID, nil)); err != nil {
+		return nil, fmt.Errorf("instagram: archiving follows: %w", err)
+	}
+	if err := writeArchiveManifest(a.Dir, m); err != nil {
+		return nil, err
+	}
+	a.progress("followers")
+	if m.FollowedBy, stats.FollowedBy, err = mergeUsers(ctx, m.FollowedBy, a.Client.Relationships.FollowedByIterator(userID, nil)); err != nil {
+		return nil, fmt.Errorf("instagram: archiving followers: %w", err)
+	}
+	if err := writeArchiveManifest(a.Dir, m); err != nil {
+		return nil, err
+	}
+
+	if userID == "self" {
+		a.progress("liked media")
+		known := make(map[string]bool)
+		for _, md := range m.LikedMedia {
+			known[md.ID] = true
+		}
+		liked, err := collectMedia(ctx, a.Client.Users.LikedMediaIterator(nil), known)
+		if err != nil {
+			return nil, fmt.Errorf("instagram: archiving liked media: %w", err)
+		}
+		m.LikedMedia = append(liked, m.LikedMedia...)
+		stats.LikedMedia = len(liked)
+		if err := writeArchiveManifest(a.Dir, m); err != nil {
+			return nil, err
+		}
+	}
+
+	a.progress("files")
+	stats.Files, err = a.download(ctx, m)
+	m.UpdatedTime = time.Now().Unix()
+	if werr := writeArchiveManifest(a.Dir, m); werr != nil {
+		return nil, werr
+	}
+	if err == nil && failure != nil {
+		err = fmt.Errorf("instagram: archiving comments or likes of media %s: %w", strings.Join(failed, ", "), failure)
+	}
+	return stats, err
+}
+
+// archiveMedia fetches the comments and likes of am, recording the error in
+// am if it fails.
+func (a *Archiver) archiveMedia(ctx context.Context, am *ArchivedMedia) error {
+	comments, _, err := a.Client.Comments.MediaCommentsContext(ctx, am.Media.ID)
+	if err != nil {
+		am.Error = err.Error()
+		return err
+	}
+	likes, _, err := a.Client.Likes.MediaLikesContext(ctx, am.Media.ID)
+	if err != nil {
+		am.Error = err.Error()
+		return err
+	}
+	am.Comments, am.Likes, am.Error = comments, likes, ""
+	return nil
+}
+
+// download downloads the files of the media of m lacking one and returns how
+// many were downloaded.
+func (a *Archiver) download(ctx context.Context, m *ArchiveManifest) (int, error) {
+	var missing []*ArchivedMedia
+	var media []Media
+	for _, am := range m.Media {
+		if am.File == "" {
+			missing = append(missing, am)
+			media = append(media, am.Media)
+		}
+	}
+	if len(media) == 0 {
+		return 0, nil
+	}
+
+	d := new(Downloader)
+	if a.Downloader != nil {
+		*d = *a.Downloader
+	}
+	d.Dir = filepath.Join(a.Dir, "media")
+	downloads, err := d.Download(ctx, media)
+
+	n := 0
+	for i, dl := range downloads {
+		if dl.Err != nil {
+			continue
+		}
+		missing[i].File = filepath.ToSlash(filepath.Join("media", filepath.Base(dl.Path)))
+		if !dl.Skipped {
+			n++
+		}
+	}
+	return n, err
+}
+
+func (a *Archiver) progress(step string) {
+	if a.OnProgress != nil {
+		a.OnProgress(step)
+	}
+}
+
+// collectMedia returns the media of it up to the first known one.
+func collectMedia(ctx context.Context, it *MediaIterator, known map[string]bool) ([]Media, error) {
+	it.Stop = func(m *Media) bool { return known[m.ID] }
+	var media []Media
+	for it.Next(ctx) {
+		media = append(media, *it.Media())
+	}
+	return media, it.Err()
+}
+
+// mergeUsers appends to users those of it not in it yet, and returns how many
+// were added.
+func mergeUsers(ctx context.Context, users []User, it *UserIterator) ([]User, int, error) {
+	known := make(map[string]bool)
+	for _, u := range users {
+		known[u.ID] = true
+	}
+	n := 0
+	for it.Next(ctx) {
+		if u := it.User(); !known[u.ID] {
+			known[u.ID] = true
+			users = append(users, *u)
+			n++
+		}
+	}
+	return users, n, it.Err()
+}
+
+// ReadArchiveManifest reads the manifest of the archive in dir.
+func ReadArchiveManifest(dir string) (*ArchiveManifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	m := new(ArchiveManifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("instagram: reading archive manifest: %w", err)
+	}
+	return m, nil
+}
+
+// writeArchiveManifest replaces the manifest of the archive in dir with m.
+func writeArchiveManifest(dir string, m *ArchiveManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, ManifestFile+".tmp")
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, ManifestFile))
+}
@@ -0,0 +1,227 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArchiver_Archive(t *testing.T) {
+	setup()
+	defer teardown()
+
+	posts := []string{"2", "1"}
+	followers := `{"id":"10"}`
+	mediaJSON := func(ids []string) string {
+		var media []string
+		for _, id := range ids {
+			media = append(media, fmt.Sprintf(`{"id":%q, "images":{"standard_resolution":{"url":"%s/files/%s.jpg"}}}`, id, server.URL, id))
+		}
+		return "[" + strings.Join(media, ",") + "]"
+	}
+	requests := make(map[string]int)
+	handle := func(path string, body func() string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			requests[path]++
+			fmt.Fprintf(w, `{"data": %s}`, body())
+		})
+	}
+	handle("/users/self", func() string { return `{"id":"1", "username":"alice"}` })
+	handle("/users/self/media/recent", func() string { return mediaJSON(posts) })
+	handle("/users/self/follows", func() string { return `[{"id":"20"}]` })
+	handle("/users/self/followed-by", func() string { return "[" + followers + "]" })
+	handle("/users/self/media/liked", func() string { return mediaJSON([]string{"9"}) })
+	for _, id := range []string{"1", "2", "3"} {
+		handle("/media/"+id+"/comments", func() string { return `[{"id":"c", "text":"nice"}]` })
+		handle("/media/"+id+"/likes", func() string { return `[{"id":"30"}]` })
+	}
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		fmt.Fprint(w, "jpeg")
+	})
+
+	dir := t.TempDir()
+	a := &Archiver{Client: client, Dir: dir}
+	stats, err := a.Archive(context.Background())
+	if err != nil {
+		t.Fatalf("Archive returned error: %v", err)
+	}
+	want := &ArchiveStats{Media: 2, Follows: 1, FollowedBy: 1, LikedMedia: 1, Files: 2}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("Archive returned %+v, want %+v", stats, want)
+	}
+
+	posts = []string{"3", "2", "1"}
+	followers = `{"id":"11"}, {"id":"10"}`
+	stats, err = a.Archive(context.Background())
+	if err != nil {
+		t.Fatalf("second Archive returned error: %v", err)
+	}
+	want = &ArchiveStats{Media: 1, FollowedBy: 1, Files: 1}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("second Archive returned %+v, want %+v", stats, want)
+	}
+	if n := requests["/media/1/comments"]; n != 1 {
+		t.Errorf("comments of an archived media fetched %d times, want once", n)
+	}
+
+	m, err := ReadArchiveManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadArchiveManifest returned error: %v", err)
+	}
+	var got []string
+	for _, am := range m.Media {
+		got = append(got, am.Media.ID)
+		if len(am.Comments) != 1 || len(am.Likes) != 1 {
+			t.Errorf("media %s archived with comments %+v and likes %+v", am.Media.ID, am.Comments, am.Likes)
+		}
+		if _, err := os.Stat(filepath.Join(dir, am.File)); am.File == "" || err != nil {
+			t.Errorf("file of media %s = %q, %v", am.Media.ID, am.File, err)
+		}
+	}
+	if want := []string{"3", "2", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("archived media %v, want %v", got, want)
+	}
+	if m.User == nil || m.User.Username != "alice" || len(m.FollowedBy) != 2 || len(m.Follows) != 1 || len(m.LikedMedia) != 1 {
+		t.Errorf("manifest = %+v", m)
+	}
+	if m.UpdatedTime == 0 {
+		t.Errorf("manifest has no update time")
+	}
+}
+
+func TestArchiver_Archive_failures(t *testing.T) {
+	setup()
+	defer teardown()
+
+	fail := true
+	handle := func(path, body string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data": %s}`, body)
+		})
+	}
+	handle("/users/self", `{"id":"1"}`)
+	handle("/users/self/media/recent", fmt.Sprintf(`[{"id":"2", "images":{"standard_resolution":{"url":"%[1]s/files/2.jpg"}}},
+		{"id":"1", "images":{"standard_resolution":{"url":"%[1]s/files/1.jpg"}}}]`, server.URL))
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		fmt.Fprint(w, "jpeg")
+	})
+	handle("/media/1/comments", `[]`)
+	handle("/media/1/likes", `[]`)
+	handle("/media/2/likes", `[{"id":"30"}]`)
+	mux.HandleFunc("/media/2/comments", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"meta": {"code": 500, "error_type": "APIError", "error_message": "oops"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id":"c"}]}`)
+	})
+	mux.HandleFunc("/users/self/follows", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"data": []}`)
+	})
+	handle("/users/self/followed-by", `[]`)
+	handle("/users/self/media/liked", `[]`)
+
+	dir := t.TempDir()
+	a := &Archiver{Client: client, Dir: dir}
+	if _, err := a.Archive(context.Background()); err == nil {
+		t.Fatal("Archive returned no error, want one")
+	}
+
+	// The media were archived before the run failed on follows.
+	m, err := ReadArchiveManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadArchiveManifest returned error: %v", err)
+	}
+	if len(m.Media) != 2 || m.Media[0].Media.ID != "2" || m.Media[0].Error == "" || m.Media[1].Error != "" {
+		t.Fatalf("manifest media after a failed run = %+v", m.Media)
+	}
+
+	fail = false
+	stats, err := a.Archive(context.Background())
+	if err != nil {
+		t.Fatalf("second Archive returned error: %v", err)
+	}
+	if stats.Media != 0 {
+		t.Errorf("second Archive added %d media, want 0", stats.Media)
+	}
+	m, err = ReadArchiveManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadArchiveManifest returned error: %v", err)
+	}
+	if am := m.Media[0]; am.Error != "" || len(am.Comments) != 1 || len(am.Likes) != 1 {
+		t.Errorf("retried media = %+v", am)
+	}
+}
+
+func TestArchiver_Archive_interrupted(t *testing.T) {
+	setup()
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := true
+	handle := func(path, body string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data": %s}`, body)
+		})
+	}
+	handle("/users/self", `{"id":"1"}`)
+	handle("/users/self/media/recent", fmt.Sprintf(`[{"id":"2", "images":{"standard_resolution":{"url":"%[1]s/files/2.jpg"}}},
+		{"id":"1", "images":{"standard_resolution":{"url":"%[1]s/files/1.jpg"}}}]`, server.URL))
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		fmt.Fprint(w, "jpeg")
+	})
+	handle("/users/self/follows", `[]`)
+	handle("/users/self/followed-by", `[]`)
+	handle("/users/self/media/liked", `[]`)
+	handle("/media/1/comments", `[]`)
+	handle("/media/1/likes", `[]`)
+	handle("/media/2/likes", `[]`)
+	mux.HandleFunc("/media/2/comments", func(w http.ResponseWriter, r *http.Request) {
+		if interrupt {
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id":"c"}]}`)
+	})
+
+	dir := t.TempDir()
+	a := &Archiver{Client: client, Dir: dir}
+	if _, err := a.Archive(ctx); err != context.Canceled {
+		t.Fatalf("Archive returned error %v, want %v", err, context.Canceled)
+	}
+	m, err := ReadArchiveManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadArchiveManifest returned error: %v", err)
+	}
+	if len(m.Media) != 2 || m.Media[0].Error == "" || m.Media[1].Error != "" {
+		t.Errorf("interrupted manifest media = %+v", m.Media)
+	}
+
+	interrupt = false
+	if _, err := a.Archive(context.Background()); err != nil {
+		t.Fatalf("second Archive returned error: %v", err)
+	}
+	if m, _ = ReadArchiveManifest(dir); len(m.Media) != 2 || m.Media[0].Error != "" || len(m.Media[0].Comments) != 1 {
+		t.Errorf("resumed manifest media = %+v", m.Media)
+	}
+}
@@ -6,6 +6,7 @@
 package instagram
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
@@ -30,33 +31,45 @@ type Comment struct {
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/comments/#get_media_comments
 func (s *CommentsService) MediaComments(mediaID string) ([]Comment, error) {
+	comments, _, err := s.MediaCommentsContext(context.Background(), mediaID)
+	return comments, err
+}
+
+// MediaCommentsContext is like MediaComments but stops when ctx is done and
+// returns the Response.
+func (s *CommentsService) MediaCommentsContext(ctx context.Context, mediaID string) ([]Comment, *Response, error) {
 	u := fmt.Sprintf("media/%v/comments", mediaID)
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	comments := new([]Comment)
-	_, err = s.client.Do(req, comments)
-	return *comments, err
+	resp, err := s.client.DoContext(ctx, req, comments)
+	return *comments, resp, err
 }
 
 // Add a comment on a media.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/comments/#post_media_comments
 func (s *CommentsService) Add(mediaID string, text []string) error {
+	_, err := s.AddContext(context.Background(), mediaID, text)
+	return err
+}
+
+// AddContext is like Add but stops when ctx is done and returns the Response.
+func (s *CommentsService) AddContext(ctx context.Context, mediaID string, text []string) (*Response, error) {
 	u := fmt.Sprintf("media/%v/comments", mediaID)
 	params := url.Values{
 		"text": text,
 	}
 
-	req, err := s.client.NewRequest("POST", u, params.Encode())
+	req, err := s.client.NewRequestWithContext(ctx, "POST", u, params.Encode())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = s.client.Do(req, nil)
-	return err
+	return s.client.DoContext(ctx, req, nil)
 }
 
 // Delete a comment either on the authenticated user's media or authored by
@@ -64,12 +77,18 @@ func (s *CommentsService) Add(mediaID string, text []string) error {
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/comments/#delete_media_comments
 func (s *CommentsService) Delete(mediaID, commentID string) error {
+	_, err := s.DeleteContext(context.Background(), mediaID, commentID)
+	return err
+}
+
+// DeleteContext is like Delete but stops when ctx is done and returns the
+// Response.
+func (s *CommentsService) DeleteContext(ctx context.Context, mediaID, commentID string) (*Response, error) {
 	u := fmt.Sprintf("media/%v/comments/%v", mediaID, commentID)
-	req, err := s.client.NewRequest("DELETE", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "DELETE", u, "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = s.client.Do(req, nil)
-	return err
+	return s.client.DoContext(ctx, req, nil)
 }
@@ -0,0 +1,345 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Resolution is the resolution of the images and videos of a Media.
+type Resolution string
+
+// Resolutions, from the smallest to the largest.
+const (
+	ResolutionThumbnail Resolution = "thumbnail"
+	ResolutionLow       Resolution = "low_resolution"
+	ResolutionStandard  Resolution = "standard_resolution"
+)
+
+// DefaultDownloadWorkers is the number of concurrent downloads of a Downloader
+// whose Workers is zero.
+const DefaultDownloadWorkers = 4
+
+var (
+	// ErrNoFile is returned when a media has no image or video to download.
+	ErrNoFile = errors.New("instagram: media has no file to download")
+
+	// ErrInvalidDownload is returned when a downloaded file does not have the
+	// announced size or is not of the expected content type.
+	ErrInvalidDownload = errors.New("instagram: invalid download")
+)
+
+// Downloader downloads the images and videos of media into a directory:
+//
+//	d := &instagram.Downloader{Dir: "photos", MaxWidth: 320}
+//	downloads, err := d.Download(ctx, media)
+//
+// Files are named after the media ID and the resolution, e.g.
+// "123_456_standard_resolution.jpg", so that downloading the same media again
+// skips the files already present. Files are written under a ".part" suffix
+// until complete; interrupted video downloads are resumed with a range request.
+// A Downloader is safe for concurrent use.
+type Downloader struct {
+	// HTTPClient downloads the files. http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+
+	// Dir is the directory the files are written to. It must exist.
+	Dir string
+
+	// Resolution is the resolution to download. If empty or missing from a
+	// media, the best-fitting one is chosen: the largest no wider than
+	// MaxWidth, or the smallest if none is, or the largest if MaxWidth is
+	// zero.
+	Resolution Resolution
+	MaxWidth   int
+
+	// Videos makes the videos of video media downloaded instead of their
+	// cover images.
+	Videos bool
+
+	// Workers is the number of concurrent downloads, DefaultDownloadWorkers
+	// if zero.
+	Workers int
+}
+
+// Download is the outcome of the download of a media file.
+type Download struct {
+	MediaID    string
+	Resolution Resolution
+	Video      bool
+	URL        string
+
+	// Path is the path of the file, and Size its size in bytes.
+	Path string
+	Size int64
+
+	// Skipped reports that the file had already been downloaded.
+	Skipped bool
+
+	// Err is the error the download failed with, if any.
+	Err error
+}
+
+// mediaFile is a file of a media, with the width of its resolution.
+type mediaFile struct {
+	resolution Resolution
+	url        string
+	width      int
+}
+
+// Download downloads a file for each of media and returns the outcomes in the
+// same order. The error is non-nil if any download failed; it wraps the error
+// of the first one.
+func (d *Downloader) Download(ctx context.Context, media []Media) ([]Download, error) {
+	downloads := make([]Download, len(media))
+	workers := d.Workers
+	if workers <= 0 {
+		workers = DefaultDownloadWorkers
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				downloads[j] = d.download(ctx, &media[j])
+			}
+		}()
+	}
+	for i := range media {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	var first error
+	for _, dl := range downloads {
+		if dl.Err != nil {
+			if first == nil {
+				first = dl.Err
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return downloads, fmt.Errorf("instagram: %d of %d downloads failed: %w", failed, len(media), first)
+	}
+	return downloads, nil
+}
+
+// download downloads the file chosen for m.
+func (d *Downloader) download(ctx context.Context, m *Media) Download {
+	dl := Download{MediaID: m.ID}
+	f, video, ok := d.choose(m)
+	if !ok {
+		dl.Err = fmt.Errorf("%w: media %s", ErrNoFile, m.ID)
+		return dl
+	}
+	dl.Resolution, dl.Video, dl.URL = f.resolution, video, f.url
+
+	ext := ".jpg"
+	if video {
+		ext = ".mp4"
+	}
+	if u, err := url.Parse(f.url); err == nil && path.Ext(u.Path) != "" {
+		ext = path.Ext(u.Path)
+	}
+	dl.Path = filepath.Join(d.Dir, m.ID+"_"+string(f.resolution)+ext)
+
+	if fi, err := os.Stat(dl.Path); err == nil {
+		dl.Size, dl.Skipped = fi.Size(), true
+		return dl
+	}
+	dl.Size, dl.Err = d.fetch(ctx, f.url, dl.Path, video)
+	if dl.Err != nil {
+		dl.Err = fmt.Errorf("instagram: downloading media %s: %w", m.ID, dl.Err)
+	}
+	return dl
+}
+
+// choose returns the file of m to download, and whether it is a video.
+func (d *Downloader) choose(m *Media) (mediaFile, bool, bool) {
+	if d.Videos && m.Videos != nil {
+		f, ok := d.best([]mediaFile{
+			videoFile(ResolutionLow, m.Videos.LowResolution),
+			videoFile(ResolutionStandard, m.Videos.StandardResolution),
+		})
+		if ok {
+			return f, true, true
+		}
+		// Without a video URL, the image still is worth having.
+	}
+	if m.Images == nil {
+		return mediaFile{}, false, false
+	}
+	f, ok := d.best([]mediaFile{
+		imageFile(ResolutionThumbnail, m.Images.Thumbnail),
+		imageFile(ResolutionLow, m.Images.LowResolution),
+		imageFile(ResolutionStandard, m.Images.StandardResolution),
+	})
+	return f, false, ok
+}
+
+// best returns the file to download among files, ordered by increasing
+// resolution, ignoring those without a URL.
+func (d *Downloader) best(files []mediaFile) (mediaFile, bool) {
+	var available []mediaFile
+	for _, f := range files {
+		if f.url == "" {
+			continue
+		}
+		if f.resolution == d.Resolution {
+			return f, true
+		}
+		available = append(available, f)
+	}
+	if len(available) == 0 {
+		return mediaFile{}, false
+	}
+
+	best := available[len(available)-1]
+	if d.MaxWidth > 0 {
+		best = available[0]
+		for _, f := range available {
+			if f.width <= d.MaxWidth {
+				best = f
+			}
+		}
+	}
+	return best, true
+}
+
+func imageFile(r Resolution, img *MediaImage) mediaFile {
+	if img == nil {
+		return mediaFile{resolution: r}
+	}
+	return mediaFile{resolution: r, url: img.URL, width: img.Width}
+}
+
+func videoFile(r Resolution, v *MediaVideo) mediaFile {
+	if v == nil {
+		return mediaFile{resolution: r}
+	}
+	return mediaFile{resolution: r, url: v.URL, width: v.Width}
+}
+
+// fetch downloads rawurl into name and returns its size. The file is written
+// to name+".part" first; for videos, an existing part is resumed.
+func (d *Downloader) fetch(ctx context.Context, rawurl, name string, video bool) (int64, error) {
+	part := name + ".part"
+	var offset int64
+	if video {
+		if fi, err := os.Stat(part); err == nil {
+			offset = fi.Size()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawurl, nil)
+	if err != nil {
+		return 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	client := d.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The part is complete but was not renamed, e.g. because the
+		// process was interrupted.
+		if size, ok := rangeSize(resp.Header.Get("Content-Range")); !ok || size != offset {
+			return 0, fmt.Errorf("GET %s: %s", rawurl, resp.Status)
+		}
+		if err := os.Rename(part, name); err != nil {
+			return 0, err
+		}
+		return offset, nil
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		if start, ok := rangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			return 0, fmt.Errorf("%w: unexpected Content-Range %q", ErrInvalidDownload, resp.Header.Get("Content-Range"))
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+	default:
+		return 0, fmt.Errorf("GET %s: %s", rawurl, resp.Status)
+	}
+
+	want := "image/"
+	if video {
+		want = "video/"
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, want) {
+		return 0, fmt.Errorf("%w: content type %q, want %s*", ErrInvalidDownload, ct, want)
+	}
+
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && resp.ContentLength >= 0 && n != resp.ContentLength {
+		err = fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidDownload, n, resp.ContentLength)
+	}
+	if err != nil {
+		if !video {
+			os.Remove(part)
+		}
+		return 0, err
+	}
+	if err := os.Rename(part, name); err != nil {
+		return 0, err
+	}
+	return offset + n, nil
+}
+
+// rangeStart returns the first byte position of a Content-Range header.
+func rangeStart(contentRange string) (int64, bool) {
+	s := strings.TrimPrefix(contentRange, "bytes ")
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		start, err := strconv.ParseInt(s[:i], 10, 64)
+		return start, err == nil
+	}
+	return 0, false
+}
+
+// rangeSize returns the complete length of an unsatisfied range
+// Content-Range header, "bytes */length".
+func rangeSize(contentRange string) (int64, bool) {
+	s := strings.TrimPrefix(contentRange, "bytes */")
+	if s == contentRange {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(s, 10, 64)
+	return size, err == nil
+}
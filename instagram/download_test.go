@@ -0,0 +1,223 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDownloader_choose(t *testing.T) {
+	m := &Media{
+		ID: "1_1",
+		Images: &MediaImages{
+			Thumbnail:          &MediaImage{URL: "t", Width: 150},
+			LowResolution:      &MediaImage{URL: "l", Width: 306},
+			StandardResolution: &MediaImage{URL: "s", Width: 612},
+		},
+		Videos: &MediaVideos{
+			LowResolution:      &MediaVideo{URL: "vl", Width: 480},
+			StandardResolution: &MediaVideo{URL: "vs", Width: 640},
+		},
+	}
+
+	tests := []struct {
+		d     Downloader
+		url   string
+		video bool
+	}{
+		{Downloader{}, "s", false},
+		{Downloader{Resolution: ResolutionThumbnail}, "t", false},
+		{Downloader{MaxWidth: 400}, "l", false},
+		{Downloader{MaxWidth: 100}, "t", false},
+		{Downloader{Videos: true}, "vs", true},
+		{Downloader{Videos: true, MaxWidth: 500}, "vl", true},
+		{Downloader{Videos: true, Resolution: ResolutionThumbnail, MaxWidth: 500}, "vl", true},
+	}
+	for _, tt := range tests {
+		f, video, ok := tt.d.choose(m)
+		if !ok || f.url != tt.url || video != tt.video {
+			t.Errorf("%+v chose %+v, %v, %v, want %q, %v", tt.d, f, video, ok, tt.url, tt.video)
+		}
+	}
+
+	noVideo := *m
+	noVideo.Videos = &MediaVideos{StandardResolution: &MediaVideo{}}
+	if f, video, ok := (&Downloader{Videos: true}).choose(&noVideo); !ok || f.url != "s" || video {
+		t.Errorf("choose of a video without URLs returned %+v, %v, %v, want the image", f, video, ok)
+	}
+
+	if _, _, ok := (&Downloader{}).choose(&Media{ID: "2_1"}); ok {
+		t.Errorf("choose returned a file for a media without images")
+	}
+}
+
+// fileServer serves files with the content type of their extension,
+// supporting range requests.
+type fileServer struct {
+	mu       sync.Mutex
+	files    map[string][]byte
+	requests []string
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data, ok := s.files[r.URL.Path]
+	s.requests = append(s.requests, r.URL.Path+" "+r.Header.Get("Range"))
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, ".txt") {
+		w.Header().Set("Content-Type", "text/plain")
+	}
+	http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+}
+
+func TestDownloader_Download(t *testing.T) {
+	fs := &fileServer{files: map[string][]byte{
+		"/a.jpg":  bytes.Repeat([]byte("a"), 1000),
+		"/b.jpg":  bytes.Repeat([]byte("b"), 2000),
+		"/c.txt":  []byte("not an image"),
+		"/v.mp4":  bytes.Repeat([]byte("v"), 5000),
+		"/vt.jpg": []byte("cover"),
+	}}
+	srv := httptest.NewServer(fs)
+	defer srv.Close()
+
+	image := func(id, path string) Media {
+		return Media{ID: id, Images: &MediaImages{StandardResolution: &MediaImage{URL: srv.URL + path}}}
+	}
+	video := Media{
+		ID:     "4_1",
+		Images: &MediaImages{StandardResolution: &MediaImage{URL: srv.URL + "/vt.jpg"}},
+		Videos: &MediaVideos{StandardResolution: &MediaVideo{URL: srv.URL + "/v.mp4"}},
+	}
+	media := []Media{image("1_1", "/a.jpg"), image("2_1", "/b.jpg"), image("3_1", "/c.txt"), video, {ID: "5_1"}}
+
+	dir := t.TempDir()
+	// A partial video left by an interrupted download.
+	if err := ioutil.WriteFile(filepath.Join(dir, "4_1_standard_resolution.mp4.part"), bytes.Repeat([]byte("v"), 3000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Downloader{Dir: dir, Videos: true, Workers: 2}
+	downloads, err := d.Download(context.Background(), media)
+	if err == nil || !errors.Is(err, ErrInvalidDownload) {
+		t.Errorf("Download returned error %v, want one wrapping ErrInvalidDownload", err)
+	}
+	if len(downloads) != len(media) {
+		t.Fatalf("Download returned %d downloads, want %d", len(downloads), len(media))
+	}
+
+	for i, want := range []struct {
+		name string
+		size int64
+	}{
+		{"1_1_standard_resolution.jpg", 1000},
+		{"2_1_standard_resolution.jpg", 2000},
+		{},
+		{"4_1_standard_resolution.mp4", 5000},
+	} {
+		dl := downloads[i]
+		if want.name == "" {
+			continue
+		}
+		if dl.Err != nil || filepath.Base(dl.Path) != want.name || dl.Size != want.size || dl.Skipped {
+			t.Errorf("download %d = %+v, want %s of %d bytes", i, dl, want.name, want.size)
+		}
+		if fi, err := os.Stat(dl.Path); err != nil || fi.Size() != want.size {
+			t.Errorf("file %s: %v, %v", dl.Path, fi, err)
+		}
+	}
+	if !errors.Is(downloads[2].Err, ErrInvalidDownload) {
+		t.Errorf("download of a text file returned error %v", downloads[2].Err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "3_1_standard_resolution.txt.part")); !os.IsNotExist(err) {
+		t.Errorf("failed image download left a part file: %v", err)
+	}
+	if !errors.Is(downloads[4].Err, ErrNoFile) {
+		t.Errorf("download of a media without files returned error %v", downloads[4].Err)
+	}
+	if !downloads[3].Video {
+		t.Errorf("video download not reported as a video")
+	}
+
+	var resumed bool
+	for _, r := range fs.requests {
+		if r == "/v.mp4 bytes=3000-" {
+			resumed = true
+		}
+	}
+	if !resumed {
+		t.Errorf("partial video not resumed; requests: %q", fs.requests)
+	}
+
+	fs.requests = nil
+	downloads, _ = d.Download(context.Background(), media[:2])
+	if !downloads[0].Skipped || !downloads[1].Skipped || len(fs.requests) != 0 {
+		t.Errorf("second download got %+v with requests %q, want files skipped", downloads, fs.requests)
+	}
+}
+
+func TestDownloader_truncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("short"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	m := Media{ID: "1_1", Videos: &MediaVideos{LowResolution: &MediaVideo{URL: srv.URL + "/v.mp4"}}}
+	downloads, err := (&Downloader{Dir: dir, Videos: true}).Download(context.Background(), []Media{m})
+	if err == nil || downloads[0].Err == nil {
+		t.Fatalf("Download of a truncated file returned no error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1_1_low_resolution.mp4")); !os.IsNotExist(err) {
+		t.Errorf("truncated download was completed: %v", err)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "1_1_low_resolution.mp4.part")); err != nil || fi.Size() != 5 {
+		t.Errorf("truncated video part: %v, %v, want 5 bytes kept for resuming", fi, err)
+	}
+}
+
+func TestDownloader_completePart(t *testing.T) {
+	fs := &fileServer{files: map[string][]byte{"/v.mp4": bytes.Repeat([]byte("v"), 5000)}}
+	srv := httptest.NewServer(fs)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	// A complete video whose part was not renamed.
+	if err := ioutil.WriteFile(filepath.Join(dir, "1_1_low_resolution.mp4.part"), bytes.Repeat([]byte("v"), 5000), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := Media{ID: "1_1", Videos: &MediaVideos{LowResolution: &MediaVideo{URL: srv.URL + "/v.mp4"}}}
+	downloads, err := (&Downloader{Dir: dir, Videos: true}).Download(context.Background(), []Media{m})
+	if err != nil {
+		t.Fatalf("Download returned error: %v", err)
+	}
+	if dl := downloads[0]; dl.Size != 5000 {
+		t.Errorf("download = %+v, want 5000 bytes", dl)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "1_1_low_resolution.mp4")); err != nil || fi.Size() != 5000 {
+		t.Errorf("complete part not renamed: %v, %v", fi, err)
+	}
+	if len(fs.requests) != 1 || fs.requests[0] != "/v.mp4 bytes=5000-" {
+		t.Errorf("requests = %q", fs.requests)
+	}
+}
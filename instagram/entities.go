@@ -0,0 +1,287 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"strings"
+	"unicode"
+)
+
+// EntityType is the kind of an Entity.
+type EntityType string
+
+// Entity types.
+const (
+	EntityHashtag EntityType = "hashtag"
+	EntityMention EntityType = "mention"
+	EntityURL     EntityType = "url"
+	EntityEmoji   EntityType = "emoji"
+)
+
+// maxUsernameLength is the maximum length of Instagram usernames.
+const maxUsernameLength = 30
+
+// Entity is a hashtag, mention, URL or emoji found in a text.
+type Entity struct {
+	Type EntityType
+
+	// Start and End are the offsets, in runes, of the entity in the text;
+	// End is exclusive.
+	Start int
+	End   int
+
+	// Text is the entity as written in the text, e.g. "#Paris".
+	Text string
+
+	// Value is the normalized entity: the lowercased tag name or username,
+	// without the leading # or @; the URL, with "http://" added to those
+	// starting with "www."; or the emoji itself.
+	Value string
+}
+
+// Entities returns the entities of the caption text. See ParseEntities.
+func (c *MediaCaption) Entities() []Entity {
+	return ParseEntities(c.Text)
+}
+
+// Entities returns the entities of the comment text. See ParseEntities.
+func (c *Comment) Entities() []Entity {
+	return ParseEntities(c.Text)
+}
+
+// ParseEntities returns the entities of text, in order, following the rules
+// Instagram links text with:
+//
+//   - A hashtag is a # followed by letters, marks, digits and underscores in
+//     any script, with at least one letter, e.g. "#café" or "#東京". It must
+//     not follow a letter or digit, except the end of another hashtag, as in
+//     "#one#two".
+//   - A mention is an @ followed by up to 30 ASCII letters, digits,
+//     underscores and periods, not ending with a period. It must not follow a
+//     letter or digit, so that email addresses are left out.
+//   - A URL starts with "http://", "https://" or "www." and extends up to the
+//     next space; trailing punctuation and unbalanced closing brackets are
+//     left out, so that "(see http://example.com/a_(b)), ok" gives
+//     "http://example.com/a_(b)".
+//   - An emoji is a pictograph with its variation selectors, skin tone
+//     modifiers and zero-width-joined sequences, a pair of regional indicators
+//     forming a flag, or a keycap such as "1️⃣".
+func ParseEntities(text string) []Entity {
+	rs := []rune(text)
+	var entities []Entity
+	lastTagEnd := -1
+
+	for i := 0; i < len(rs); {
+		var e Entity
+		end := i
+		switch {
+		case isEmojiStart(rs, i):
+			end = scanEmoji(rs, i)
+			e = Entity{Type: EntityEmoji, Value: string(rs[i:end])}
+
+		case isURLStart(rs, i):
+			end = scanURL(rs, i)
+			e = Entity{Type: EntityURL, Value: string(rs[i:end])}
+			if strings.HasPrefix(strings.ToLower(e.Value), "www.") {
+				e.Value = "http://" + e.Value
+			}
+
+		case (rs[i] == '#' || rs[i] == '\uff03') && (i == lastTagEnd || !followsWord(rs, i)):
+			end = scanHashtag(rs, i)
+			if end > i {
+				e = Entity{Type: EntityHashtag, Value: strings.ToLower(string(rs[i+1 : end]))}
+				lastTagEnd = end
+			}
+
+		case (rs[i] == '@' || rs[i] == '\uff20') && !followsWord(rs, i):
+			end = scanMention(rs, i)
+			if end > i {
+				e = Entity{Type: EntityMention, Value: strings.ToLower(string(rs[i+1 : end]))}
+			}
+		}
+
+		if end <= i {
+			i++
+			continue
+		}
+		e.Start, e.End, e.Text = i, end, string(rs[i:end])
+		entities = append(entities, e)
+		i = end
+	}
+	return entities
+}
+
+// isWord reports whether r can be part of a word, which entities must not
+// follow.
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+func followsWord(rs []rune, i int) bool {
+	return i > 0 && isWord(rs[i-1])
+}
+
+// scanHashtag returns the end of the hashtag starting at i, or i if there is
+// none.
+func scanHashtag(rs []rune, i int) int {
+	j := i + 1
+	letter := false
+	for j < len(rs) && isWord(rs[j]) {
+		letter = letter || unicode.IsLetter(rs[j])
+		j++
+	}
+	if !letter {
+		return i
+	}
+	return j
+}
+
+// scanMention returns the end of the mention starting at i, or i if there is
+// none.
+func scanMention(rs []rune, i int) int {
+	j := i + 1
+	for j < len(rs) && isUsernameRune(rs[j]) {
+		j++
+	}
+	// Trailing periods end the sentence rather than the username.
+	for j > i+1 && rs[j-1] == '.' {
+		j--
+	}
+	if j == i+1 || j-i-1 > maxUsernameLength {
+		return i
+	}
+	return j
+}
+
+func isUsernameRune(r rune) bool {
+	return r < unicode.MaxASCII && (isWord(r) || r == '.')
+}
+
+// urlPrefixes start the URLs recognized in texts.
+var urlPrefixes = []string{"http://", "https://", "www."}
+
+func isURLStart(rs []rune, i int) bool {
+	if followsWord(rs, i) {
+		return false
+	}
+	for _, p := range urlPrefixes {
+		if i+len(p) < len(rs) && strings.EqualFold(string(rs[i:i+len(p)]), p) && !unicode.IsSpace(rs[i+len(p)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanURL returns the end of the URL starting at i.
+func scanURL(rs []rune, i int) int {
+	j := i
+	for j < len(rs) && !unicode.IsSpace(rs[j]) && !strings.ContainsRune(`<>"`, rs[j]) && !isEmojiStart(rs, j) {
+		j++
+	}
+
+	for j > i {
+		switch r := rs[j-1]; {
+		case strings.ContainsRune(".,;:!?'", r):
+		case r == ')' && count(rs[i:j], '(') < count(rs[i:j], ')'):
+		case r == ']' && count(rs[i:j], '[') < count(rs[i:j], ']'):
+		default:
+			return j
+		}
+		j--
+	}
+	return j
+}
+
+func count(rs []rune, r rune) int {
+	n := 0
+	for _, c := range rs {
+		if c == r {
+			n++
+		}
+	}
+	return n
+}
+
+const (
+	zeroWidthJoiner   = '\u200d'
+	variationSelector = '\ufe0f'
+	combiningKeycap   = '\u20e3'
+)
+
+// isPictograph reports whether r is displayed as an emoji by default.
+func isPictograph(r rune) bool {
+	switch {
+	case r >= 0x1f300 && r <= 0x1f5ff, // Miscellaneous Symbols and Pictographs
+		r >= 0x1f600 && r <= 0x1f64f, // Emoticons
+		r >= 0x1f680 && r <= 0x1f6ff, // Transport and Map Symbols
+		r >= 0x1f900 && r <= 0x1f9ff, // Supplemental Symbols and Pictographs
+		r >= 0x1fa70 && r <= 0x1faff, // Symbols and Pictographs Extended-A
+		r >= 0x2600 && r <= 0x27bf,   // Miscellaneous Symbols, Dingbats
+		r >= 0x1f004 && r <= 0x1f0cf, // Mahjong and playing cards
+		r >= 0x1f170 && r <= 0x1f251, // Enclosed alphanumerics and ideographs
+		r == 0x231a, r == 0x231b, r == 0x23f0, r == 0x23f3,
+		r == 0x2b50, r == 0x2b55, r == 0x2b1b, r == 0x2b1c:
+		return true
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+func isEmojiModifier(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+// isTagRune reports whether r is one of the tag characters of subdivision
+// flags, like the flag of Scotland.
+func isTagRune(r rune) bool {
+	return r >= 0xe0020 && r <= 0xe007f
+}
+
+// isEmojiStart reports whether an emoji starts at i.
+func isEmojiStart(rs []rune, i int) bool {
+	r := rs[i]
+	next := func(k int) rune {
+		if i+k < len(rs) {
+			return rs[i+k]
+		}
+		return 0
+	}
+	switch {
+	case isPictograph(r), isRegionalIndicator(r):
+		return true
+	case r >= '0' && r <= '9', r == '#', r == '*':
+		return next(1) == combiningKeycap || (next(1) == variationSelector && next(2) == combiningKeycap)
+	}
+	// Symbols with a text presentation by default, like ©, ™ or ↔, are
+	// emoji when followed by the variation selector.
+	return unicode.IsSymbol(r) && next(1) == variationSelector
+}
+
+// scanEmoji returns the end of the emoji starting at i.
+func scanEmoji(rs []rune, i int) int {
+	if isRegionalIndicator(rs[i]) {
+		if i+1 < len(rs) && isRegionalIndicator(rs[i+1]) {
+			return i + 2
+		}
+		return i + 1
+	}
+
+	j := i + 1
+	for j < len(rs) {
+		switch r := rs[j]; {
+		case r == variationSelector, r == combiningKeycap, isEmojiModifier(r), isTagRune(r):
+			j++
+		case r == zeroWidthJoiner && j+1 < len(rs) && (isPictograph(rs[j+1]) || unicode.IsSymbol(rs[j+1])):
+			j += 2
+		default:
+			return j
+		}
+	}
+	return j
+}
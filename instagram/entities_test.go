@@ -0,0 +1,74 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"reflect"
+	"testing"
+)
+
+// entityTexts returns the type and text of entities, for comparisons.
+func entityTexts(entities []Entity) [][2]string {
+	var texts [][2]string
+	for _, e := range entities {
+		texts = append(texts, [2]string{string(e.Type), e.Text})
+	}
+	return texts
+}
+
+func TestParseEntities(t *testing.T) {
+	tests := []struct {
+		text string
+		want [][2]string
+	}{
+		{"", nil},
+		{"no entities here", nil},
+		{"Sunset at #Paris, #nofilter!", [][2]string{{"hashtag", "#Paris"}, {"hashtag", "#nofilter"}}},
+		{"#one#two #3 #2013 #go_lang", [][2]string{{"hashtag", "#one"}, {"hashtag", "#two"}, {"hashtag", "#go_lang"}}},
+		{"#café #東京 #ﾗｰﾒﾝ #привет", [][2]string{{"hashtag", "#café"}, {"hashtag", "#東京"}, {"hashtag", "#ﾗｰﾒﾝ"}, {"hashtag", "#привет"}}},
+		{"a#b c&#39;s", nil},
+		{"with @alice.b. and @bob_2!", [][2]string{{"mention", "@alice.b"}, {"mention", "@bob_2"}}},
+		{"mail bob@example.com or @", nil},
+		{"@abcdefghijabcdefghijabcdefghijabcdefghij", nil},
+		{"thanks @abcdefghijabcdefghijabcdefghij.", [][2]string{{"mention", "@abcdefghijabcdefghijabcdefghij"}}},
+		{"see http://example.com/a?b=c.", [][2]string{{"url", "http://example.com/a?b=c"}}},
+		{"(see https://en.wikipedia.org/wiki/Go_(game)), ok", [][2]string{{"url", "https://en.wikipedia.org/wiki/Go_(game)"}}},
+		{"WWW.example.com/#top and http:// nothing", [][2]string{{"url", "WWW.example.com/#top"}}},
+		{"love it ❤️😍😍", [][2]string{{"emoji", "❤️"}, {"emoji", "😍"}, {"emoji", "😍"}}},
+		{"👍🏽 👨‍👩‍👧 🇫🇷🇯🇵 1️⃣ #️⃣ ©️ ©", [][2]string{{"emoji", "👍🏽"}, {"emoji", "👨‍👩‍👧"}, {"emoji", "🇫🇷"}, {"emoji", "🇯🇵"}, {"emoji", "1️⃣"}, {"emoji", "#️⃣"}, {"emoji", "©️"}}},
+		{"#sun☀️ @bob😀 www.x.com😀", [][2]string{{"hashtag", "#sun"}, {"emoji", "☀️"}, {"mention", "@bob"}, {"emoji", "😀"}, {"url", "www.x.com"}, {"emoji", "😀"}}},
+	}
+	for _, tt := range tests {
+		got := entityTexts(ParseEntities(tt.text))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseEntities(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseEntities_offsets(t *testing.T) {
+	got := ParseEntities("Été 🇫🇷 #Été @Bob www.x.com")
+	want := []Entity{
+		{Type: EntityEmoji, Start: 4, End: 6, Text: "🇫🇷", Value: "🇫🇷"},
+		{Type: EntityHashtag, Start: 7, End: 11, Text: "#Été", Value: "été"},
+		{Type: EntityMention, Start: 12, End: 16, Text: "@Bob", Value: "bob"},
+		{Type: EntityURL, Start: 17, End: 26, Text: "www.x.com", Value: "http://www.x.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseEntities returned %+v, want %+v", got, want)
+	}
+}
+
+func TestEntities_methods(t *testing.T) {
+	caption := &MediaCaption{Text: "#go"}
+	comment := &Comment{Text: "@go"}
+	if e := caption.Entities(); len(e) != 1 || e[0].Value != "go" || e[0].Type != EntityHashtag {
+		t.Errorf("MediaCaption.Entities returned %+v", e)
+	}
+	if e := comment.Entities(); len(e) != 1 || e[0].Value != "go" || e[0].Type != EntityMention {
+		t.Errorf("Comment.Entities returned %+v", e)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+type mediaColumn struct {
+	name  string
+	value func(m *instagram.Media) interface{}
+}
+
+var mediaColumns = []mediaColumn{
+	{"id", func(m *instagram.Media) interface{} { return m.ID }},
+	{"type", func(m *instagram.Media) interface{} { return m.Type }},
+	{"created_time", func(m *instagram.Media) interface{} { return orNil(m.CreatedTime) }},
+	{"created_at", func(m *instagram.Media) interface{} { return timestamp(m.CreatedTime) }},
+	{"link", func(m *instagram.Media) interface{} { return m.Link }},
+	{"user_id", func(m *instagram.Media) interface{} { return userField(m.User, "id") }},
+	{"username", func(m *instagram.Media) interface{} { return userField(m.User, "username") }},
+	{"full_name", func(m *instagram.Media) interface{} { return userField(m.User, "full_name") }},
+	{"caption", func(m *instagram.Media) interface{} {
+		if m.Caption == nil {
+			return ""
+		}
+		return m.Caption.Text
+	}},
+	{"tags", func(m *instagram.Media) interface{} {
+		if m.Tags == nil {
+			return []string{}
+		}
+		return m.Tags
+	}},
+	{"filter", func(m *instagram.Media) interface{} { return m.Filter }},
+	{"likes", func(m *instagram.Media) interface{} {
+		if m.Likes == nil {
+			return 0
+		}
+		return m.Likes.Count
+	}},
+	{"comments", func(m *instagram.Media) interface{} {
+		if m.Comments == nil {
+			return 0
+		}
+		return m.Comments.Count
+	}},
+	{"user_has_liked", func(m *instagram.Media) interface{} { return m.UserHasLiked }},
+	{"location_id", func(m *instagram.Media) interface{} {
+		if m.Location == nil || m.Location.ID == 0 {
+			return nil
+		}
+		return strconv.Itoa(m.Location.ID)
+	}},
+	{"location_name", func(m *instagram.Media) interface{} {
+		if m.Location == nil {
+			return ""
+		}
+		return m.Location.Name
+	}},
+	{"latitude", func(m *instagram.Media) interface{} {
+		if m.Location == nil || !hasCoordinates(m.Location.Latitude, m.Location.Longitude) {
+			return nil
+		}
+		return m.Location.Latitude
+	}},
+	{"longitude", func(m *instagram.Media) interface{} {
+		if m.Location == nil || !hasCoordinates(m.Location.Latitude, m.Location.Longitude) {
+			return nil
+		}
+		return m.Location.Longitude
+	}},
+	{"thumbnail_url", func(m *instagram.Media) interface{} {
+		if m.Images == nil {
+			return ""
+		}
+		return imageURL(m.Images.Thumbnail)
+	}},
+	{"low_resolution_url", func(m *instagram.Media) interface{} {
+		if m.Images == nil {
+			return ""
+		}
+		return imageURL(m.Images.LowResolution)
+	}},
+	{"standard_resolution_url", func(m *instagram.Media) interface{} {
+		if m.Images == nil {
+			return ""
+		}
+		return imageURL(m.Images.StandardResolution)
+	}},
+	{"video_url", func(m *instagram.Media) interface{} {
+		if m.Videos == nil || m.Videos.StandardResolution == nil {
+			return ""
+		}
+		return m.Videos.StandardResolution.URL
+	}},
+}
+
+func userField(u *instagram.User, field string) interface{} {
+	if u == nil {
+		return ""
+	}
+	switch field {
+	case "id":
+		return u.ID
+	case "username":
+		return u.Username
+	}
+	return u.FullName
+}
+
+func imageURL(img *instagram.MediaImage) string {
+	if img == nil {
+		return ""
+	}
+	return img.URL
+}
+
+// MediaColumns returns the names of the columns of media, in their default
+// order.
+func MediaColumns() []string {
+	names := make([]string, len(mediaColumns))
+	for i, c := range mediaColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// MediaEncoder writes media as records.
+type MediaEncoder struct {
+	enc     *encoder
+	columns []int
+}
+
+// NewMediaEncoder returns an encoder writing the given columns of media to w,
+// or all of them if none are given. See MediaColumns for the names.
+func NewMediaEncoder(w io.Writer, f Format, columns ...string) (*MediaEncoder, error) {
+	indexes, columns, err := selectColumns("media", MediaColumns(), columns)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := newEncoder(w, f, columns)
+	if err != nil {
+		return nil, err
+	}
+	return &MediaEncoder{enc: enc, columns: indexes}, nil
+}
+
+// Encode writes m.
+func (e *MediaEncoder) Encode(m *instagram.Media) error {
+	values := make([]interface{}, len(e.columns))
+	for i, c := range e.columns {
+		values[i] = mediaColumns[c].value(m)
+	}
+	return e.enc.write(values)
+}
+
+// Flush writes any buffered data, and the CSV header if nothing was encoded.
+func (e *MediaEncoder) Flush() error {
+	return e.enc.flush()
+}
+
+type userColumn struct {
+	name  string
+	value func(u *instagram.User) interface{}
+}
+
+var userColumns = []userColumn{
+	{"id", func(u *instagram.User) interface{} { return u.ID }},
+	{"username", func(u *instagram.User) interface{} { return u.Username }},
+	{"full_name", func(u *instagram.User) interface{} { return u.FullName }},
+	{"bio", func(u *instagram.User) interface{} { return u.Bio }},
+	{"website", func(u *instagram.User) interface{} { return u.Website }},
+	{"profile_picture", func(u *instagram.User) interface{} { return u.ProfilePicture }},
+	{"media", func(u *instagram.User) interface{} {
+		if u.Counts == nil {
+			return nil
+		}
+		return u.Counts.Media
+	}},
+	{"follows", func(u *instagram.User) interface{} {
+		if u.Counts == nil {
+			return nil
+		}
+		return u.Counts.Follows
+	}},
+	{"followed_by", func(u *instagram.User) interface{} {
+		if u.Counts == nil {
+			return nil
+		}
+		return u.Counts.FollowedBy
+	}},
+}
+
+// UserColumns returns the names of the columns of users, in their default
+// order. The counts are empty for users listed without them.
+func UserColumns() []string {
+	names := make([]string, len(userColumns))
+	for i, c := range userColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// UserEncoder writes users as records.
+type UserEncoder struct {
+	enc     *encoder
+	columns []int
+}
+
+// NewUserEncoder returns an encoder writing the given columns of users to w,
+// or all of them if none are given. See UserColumns for the names.
+func NewUserEncoder(w io.Writer, f Format, columns ...string) (*UserEncoder, error) {
+	indexes, columns, err := selectColumns("user", UserColumns(), columns)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := newEncoder(w, f, columns)
+	if err != nil {
+		return nil, err
+	}
+	return &UserEncoder{enc: enc, columns: indexes}, nil
+}
+
+// Encode writes u.
+func (e *UserEncoder) Encode(u *instagram.User) error {
+	values := make([]interface{}, len(e.columns))
+	for i, c := range e.columns {
+		values[i] = userColumns[c].value(u)
+	}
+	return e.enc.write(values)
+}
+
+// Flush writes any buffered data, and the CSV header if nothing was encoded.
+func (e *UserEncoder) Flush() error {
+	return e.enc.flush()
+}
+
+type commentColumn struct {
+	name  string
+	value func(c *instagram.Comment) interface{}
+}
+
+var commentColumns = []commentColumn{
+	{"id", func(c *instagram.Comment) interface{} { return c.ID }},
+	{"created_time", func(c *instagram.Comment) interface{} { return orNil(c.CreatedTime) }},
+	{"created_at", func(c *instagram.Comment) interface{} { return timestamp(c.CreatedTime) }},
+	{"user_id", func(c *instagram.Comment) interface{} { return userField(c.From, "id") }},
+	{"username", func(c *instagram.Comment) interface{} { return userField(c.From, "username") }},
+	{"full_name", func(c *instagram.Comment) interface{} { return userField(c.From, "full_name") }},
+	{"text", func(c *instagram.Comment) interface{} { return c.Text }},
+}
+
+// CommentColumns returns the names of the columns of comments, in their
+// default order.
+func CommentColumns() []string {
+	names := make([]string, len(commentColumns))
+	for i, c := range commentColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// CommentEncoder writes comments as records.
+type CommentEncoder struct {
+	enc     *encoder
+	columns []int
+}
+
+// NewCommentEncoder returns an encoder writing the given columns of comments
+// to w, or all of them if none are given. See CommentColumns for the names.
+func NewCommentEncoder(w io.Writer, f Format, columns ...string) (*CommentEncoder, error) {
+	indexes, columns, err := selectColumns("comment", CommentColumns(), columns)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := newEncoder(w, f, columns)
+	if err != nil {
+		return nil, err
+	}
+	return &CommentEncoder{enc: enc, columns: indexes}, nil
+}
+
+// Encode writes c.
+func (e *CommentEncoder) Encode(c *instagram.Comment) error {
+	values := make([]interface{}, len(e.columns))
+	for i, col := range e.columns {
+		values[i] = commentColumns[col].value(c)
+	}
+	return e.enc.write(values)
+}
+
+// Flush writes any buffered data, and the CSV header if nothing was encoded.
+func (e *CommentEncoder) Flush() error {
+	return e.enc.flush()
+}
+
+type locationColumn struct {
+	name  string
+	value func(l *instagram.Location) interface{}
+}
+
+var locationColumns = []locationColumn{
+	{"id", func(l *instagram.Location) interface{} { return l.ID }},
+	{"name", func(l *instagram.Location) interface{} { return l.Name }},
+	{"latitude", func(l *instagram.Location) interface{} {
+		if !hasCoordinates(l.Latitude, l.Longitude) {
+			return nil
+		}
+		return l.Latitude
+	}},
+	{"longitude", func(l *instagram.Location) interface{} {
+		if !hasCoordinates(l.Latitude, l.Longitude) {
+			return nil
+		}
+		return l.Longitude
+	}},
+}
+
+// hasCoordinates reports whether a location has coordinates. The API gives
+// locations known only by their name a latitude and longitude of 0, so the
+// point 0,0 itself is taken as no coordinates.
+func hasCoordinates(lat, lng float64) bool {
+	return lat != 0 || lng != 0
+}
+
+// LocationColumns returns the names of the columns of locations, in their
+// default order.
+func LocationColumns() []string {
+	names := make([]string, len(locationColumns))
+	for i, c := range locationColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// LocationEncoder writes locations as records.
+type LocationEncoder struct {
+	enc     *encoder
+	columns []int
+}
+
+// NewLocationEncoder returns an encoder writing the given columns of
+// locations to w, or all of them if none are given. See LocationColumns for
+// the names.
+func NewLocationEncoder(w io.Writer, f Format, columns ...string) (*LocationEncoder, error) {
+	indexes, columns, err := selectColumns("location", LocationColumns(), columns)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := newEncoder(w, f, columns)
+	if err != nil {
+		return nil, err
+	}
+	return &LocationEncoder{enc: enc, columns: indexes}, nil
+}
+
+// Encode writes l.
+func (e *LocationEncoder) Encode(l *instagram.Location) error {
+	values := make([]interface{}, len(e.columns))
+	for i, c := range e.columns {
+		values[i] = locationColumns[c].value(l)
+	}
+	return e.enc.write(values)
+}
+
+// Flush writes any buffered data, and the CSV header if nothing was encoded.
+func (e *LocationEncoder) Flush() error {
+	return e.enc.flush()
+}
@@ -0,0 +1,208 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package export writes media, users, comments and locations as flat records,
+// in CSV or JSON Lines, for spreadsheets and analysis tools.
+//
+// Each kind of record has an encoder writing the selected columns, or all of
+// them in a stable order when none are selected:
+//
+//	enc, err := export.NewMediaEncoder(os.Stdout, export.CSV, "id", "username", "likes", "caption")
+//	it := client.Tags.RecentMediaIterator("nofilter", nil)
+//	for it.Next(ctx) {
+//		if err := enc.Encode(it.Media()); err != nil {
+//			// ...
+//		}
+//	}
+//	err = enc.Flush()
+//
+// Records are written as they are encoded, so that large crawls need not be
+// held in memory.
+//
+// Geotagged media and locations can also be written as GeoJSON or KML for
+// mapping tools, with MediaGeoJSON, LocationsGeoJSON, MediaKML and
+// LocationsKML.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is an output format of the encoders.
+type Format int
+
+// Formats.
+const (
+	// CSV writes a header line with the column names, then a line per
+	// record. Lists, like tags, are separated by spaces. Texts starting with
+	// a character spreadsheets read as a formula, like "=", are prefixed
+	// with a quote.
+	CSV Format = iota
+
+	// JSONLines writes a JSON object per line, with the column names as
+	// keys, in column order. Numbers, booleans and lists keep their types.
+	JSONLines
+)
+
+// encoder writes rows of values in a format.
+type encoder struct {
+	w       io.Writer
+	format  Format
+	columns []string
+	csv     *csv.Writer
+	header  bool
+}
+
+func newEncoder(w io.Writer, f Format, columns []string) (*encoder, error) {
+	e := &encoder{w: w, format: f, columns: columns}
+	switch f {
+	case CSV:
+		e.csv = csv.NewWriter(w)
+	case JSONLines:
+	default:
+		return nil, fmt.Errorf("export: unknown format %d", f)
+	}
+	return e, nil
+}
+
+// writeHeader writes the CSV header, once.
+func (e *encoder) writeHeader() error {
+	if e.header || e.csv == nil {
+		return nil
+	}
+	e.header = true
+	return e.csv.Write(e.columns)
+}
+
+// write writes a row of values, one per column.
+func (e *encoder) write(values []interface{}) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	if e.csv != nil {
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = csvValue(v)
+		}
+		return e.csv.Write(record)
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.columns[i])
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteString("}\n")
+	_, err := e.w.Write(b.Bytes())
+	return err
+}
+
+// flush writes the CSV header if no record was written, and any buffered
+// data.
+func (e *encoder) flush() error {
+	if e.csv == nil {
+		return nil
+	}
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func csvValue(v interface{}) string {
+	switch v.(type) {
+	case string, []string:
+		return csvText(text(v))
+	}
+	return text(v)
+}
+
+// text formats values for text outputs.
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return strings.Join(v, " ")
+	}
+	return fmt.Sprint(v)
+}
+
+// csvText escapes texts spreadsheets would read as formulas, which captions
+// and comments written by anyone must not be.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// selectColumns returns the indexes of columns among names, or all of them
+// if columns is empty.
+func selectColumns(kind string, names, columns []string) ([]int, []string, error) {
+	if len(columns) == 0 {
+		columns = names
+	}
+	indexes := make([]int, len(columns))
+	for i, c := range columns {
+		indexes[i] = -1
+		for j, name := range names {
+			if c == name {
+				indexes[i] = j
+				break
+			}
+		}
+		if indexes[i] < 0 {
+			return nil, nil, fmt.Errorf("export: unknown %s column %q", kind, c)
+		}
+	}
+	return indexes, columns, nil
+}
+
+// timestamp formats Unix times for spreadsheets, or nil if unset.
+func timestamp(t int64) interface{} {
+	if t == 0 {
+		return nil
+	}
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
+
+// orNil turns the zero values of optional fields into empty cells and nulls.
+func orNil(v interface{}) interface{} {
+	switch v {
+	case "", 0, int64(0):
+		return nil
+	}
+	return v
+}
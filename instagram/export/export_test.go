@@ -0,0 +1,149 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+var testMedia = []instagram.Media{
+	{
+		ID:          "1_10",
+		Type:        "image",
+		CreatedTime: 1380000000,
+		User:        &instagram.User{ID: "10", Username: "alice"},
+		Caption:     &instagram.MediaCaption{Text: "sunset, \"golden\"\nhour"},
+		Tags:        []string{"sunset", "paris"},
+		Likes:       &instagram.MediaLikes{Count: 3},
+		Location:    &instagram.MediaLocation{ID: 7, Name: "Paris", Latitude: 48.85, Longitude: 2.35},
+		Images:      &instagram.MediaImages{Thumbnail: &instagram.MediaImage{URL: "http://t/1.jpg"}},
+	},
+	{ID: "2_10"},
+}
+
+func TestMediaEncoder_CSV(t *testing.T) {
+	var b bytes.Buffer
+	enc, err := NewMediaEncoder(&b, CSV, "id", "created_at", "username", "caption", "tags", "likes", "location_id", "latitude", "thumbnail_url")
+	if err != nil {
+		t.Fatalf("NewMediaEncoder returned error: %v", err)
+	}
+	for i := range testMedia {
+		if err := enc.Encode(&testMedia[i]); err != nil {
+			t.Fatalf("Encode returned error: %v", err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	records, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not CSV: %v", err)
+	}
+	want := [][]string{
+		{"id", "created_at", "username", "caption", "tags", "likes", "location_id", "latitude", "thumbnail_url"},
+		{"1_10", "2013-09-24T05:20:00Z", "alice", "sunset, \"golden\"\nhour", "sunset paris", "3", "7", "48.85", "http://t/1.jpg"},
+		{"2_10", "", "", "", "", "0", "", "", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CSV records = %q, want %q", records, want)
+	}
+}
+
+func TestMediaEncoder_JSONLines(t *testing.T) {
+	var b bytes.Buffer
+	enc, err := NewMediaEncoder(&b, JSONLines, "id", "tags", "likes", "latitude")
+	if err != nil {
+		t.Fatalf("NewMediaEncoder returned error: %v", err)
+	}
+	for i := range testMedia {
+		enc.Encode(&testMedia[i])
+	}
+	enc.Flush()
+
+	want := `{"id":"1_10","tags":["sunset","paris"],"likes":3,"latitude":48.85}
+{"id":"2_10","tags":[],"likes":0,"latitude":null}
+`
+	if b.String() != want {
+		t.Errorf("JSON Lines = %s, want %s", b.String(), want)
+	}
+}
+
+func TestMediaEncoder_CSV_values(t *testing.T) {
+	var b bytes.Buffer
+	enc, _ := NewMediaEncoder(&b, CSV, "caption", "latitude", "longitude")
+	enc.Encode(&instagram.Media{
+		Caption:  &instagram.MediaCaption{Text: `=HYPERLINK("http://evil", "x")`},
+		Location: &instagram.MediaLocation{Latitude: 0, Longitude: -0.5},
+	})
+	enc.Encode(&instagram.Media{Caption: &instagram.MediaCaption{Text: "nowhere"}, Location: &instagram.MediaLocation{Name: "Somewhere"}})
+	for _, text := range []string{"+1", "-1", "@sum", "\tx", "\rx", "a=b"} {
+		enc.Encode(&instagram.Media{Caption: &instagram.MediaCaption{Text: text}})
+	}
+	enc.Flush()
+
+	records, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not CSV: %v", err)
+	}
+	want := [][]string{
+		{"caption", "latitude", "longitude"},
+		{`'=HYPERLINK("http://evil", "x")`, "0", "-0.5"},
+		{"nowhere", "", ""},
+		{"'+1", "", ""},
+		{"'-1", "", ""},
+		{"'@sum", "", ""},
+		{"'\tx", "", ""},
+		{"'\rx", "", ""},
+		{"a=b", "", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CSV records = %q, want %q", records, want)
+	}
+}
+
+func TestEncoders_allColumns(t *testing.T) {
+	var b bytes.Buffer
+
+	users, _ := NewUserEncoder(&b, CSV)
+	users.Encode(&instagram.User{ID: "1", Username: "alice", Counts: &instagram.UserCount{FollowedBy: 5}})
+	users.Flush()
+
+	comments, _ := NewCommentEncoder(&b, CSV)
+	comments.Encode(&instagram.Comment{ID: "c", Text: "nice", From: &instagram.User{Username: "bob"}})
+	comments.Flush()
+
+	locations, _ := NewLocationEncoder(&b, CSV)
+	locations.Flush()
+
+	want := `id,username,full_name,bio,website,profile_picture,media,follows,followed_by
+1,alice,,,,,0,0,5
+id,created_time,created_at,user_id,username,full_name,text
+c,,,,bob,,nice
+id,name,latitude,longitude
+`
+	if b.String() != want {
+		t.Errorf("output = %s, want %s", b.String(), want)
+	}
+	if got := strings.Join(MediaColumns(), ","); !strings.HasPrefix(got, "id,type,created_time,") {
+		t.Errorf("MediaColumns = %s", got)
+	}
+}
+
+func TestNewEncoder_errors(t *testing.T) {
+	if _, err := NewMediaEncoder(nil, CSV, "id", "nope"); err == nil {
+		t.Errorf("NewMediaEncoder with an unknown column returned no error")
+	}
+	if _, err := NewUserEncoder(nil, Format(9)); err == nil {
+		t.Errorf("NewUserEncoder with an unknown format returned no error")
+	}
+}
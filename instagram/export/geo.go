@@ -0,0 +1,250 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+// DefaultMediaProperties are the properties of media features when none are
+// given. Any of MediaColumns can be used as a property.
+var DefaultMediaProperties = []string{"id", "username", "created_at", "caption", "link", "thumbnail_url"}
+
+// DefaultLocationProperties are the properties of location features when none
+// are given. Any of LocationColumns can be used as a property.
+var DefaultLocationProperties = []string{"id", "name"}
+
+// feature is a point of a map, with its properties in order.
+type feature struct {
+	kind       string
+	id         string
+	name       string
+	lat, lng   float64
+	created    int64
+	properties []string
+	values     []interface{}
+}
+
+// mediaFeatures returns the features of the geotagged media.
+func mediaFeatures(media []instagram.Media, properties []string) ([]feature, error) {
+	if len(properties) == 0 {
+		properties = DefaultMediaProperties
+	}
+	indexes, properties, err := selectColumns("media", MediaColumns(), properties)
+	if err != nil {
+		return nil, err
+	}
+
+	var features []feature
+	for i := range media {
+		m := &media[i]
+		if m.Location == nil || !hasCoordinates(m.Location.Latitude, m.Location.Longitude) {
+			continue
+		}
+		f := feature{
+			kind:       "media",
+			id:         m.ID,
+			name:       m.ID,
+			lat:        m.Location.Latitude,
+			lng:        m.Location.Longitude,
+			created:    m.CreatedTime,
+			properties: properties,
+		}
+		if m.User != nil && m.User.Username != "" {
+			f.name = m.User.Username
+		}
+		for _, c := range indexes {
+			f.values = append(f.values, mediaColumns[c].value(m))
+		}
+		features = append(features, f)
+	}
+	return features, nil
+}
+
+// locationFeatures returns the features of the locations.
+func locationFeatures(locations []instagram.Location, properties []string) ([]feature, error) {
+	if len(properties) == 0 {
+		properties = DefaultLocationProperties
+	}
+	indexes, properties, err := selectColumns("location", LocationColumns(), properties)
+	if err != nil {
+		return nil, err
+	}
+
+	var features []feature
+	for i := range locations {
+		l := &locations[i]
+		if !hasCoordinates(l.Latitude, l.Longitude) {
+			continue
+		}
+		f := feature{kind: "location", id: l.ID, name: l.Name, lat: l.Latitude, lng: l.Longitude, properties: properties}
+		for _, c := range indexes {
+			f.values = append(f.values, locationColumns[c].value(l))
+		}
+		features = append(features, f)
+	}
+	return features, nil
+}
+
+// MediaGeoJSON writes the geotagged media as a GeoJSON FeatureCollection of
+// points, with the given properties, or DefaultMediaProperties if none are
+// given. Media without coordinates are left out.
+func MediaGeoJSON(w io.Writer, media []instagram.Media, properties ...string) error {
+	features, err := mediaFeatures(media, properties)
+	if err != nil {
+		return err
+	}
+	return writeGeoJSON(w, features)
+}
+
+// LocationsGeoJSON writes the locations as a GeoJSON FeatureCollection of
+// points, with the given properties, or DefaultLocationProperties if none are
+// given. Locations without coordinates are left out.
+func LocationsGeoJSON(w io.Writer, locations []instagram.Location, properties ...string) error {
+	features, err := locationFeatures(locations, properties)
+	if err != nil {
+		return err
+	}
+	return writeGeoJSON(w, features)
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   geoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+func writeGeoJSON(w io.Writer, features []feature) error {
+	c := geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	for _, f := range features {
+		props := make(map[string]interface{}, len(f.properties))
+		for i, p := range f.properties {
+			props[p] = f.values[i]
+		}
+		c.Features = append(c.Features, geoJSONFeature{
+			Type:       "Feature",
+			ID:         f.id,
+			Geometry:   geoJSONPoint{Type: "Point", Coordinates: [2]float64{f.lng, f.lat}},
+			Properties: props,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+// MediaKML writes the geotagged media as a KML document of placemarks named
+// after their user, with the given properties as extended data, or
+// DefaultMediaProperties if none are given. The caption, if among the
+// properties, is the description of placemarks, and their id is "media-"
+// followed by the media ID. Media without coordinates are left out.
+func MediaKML(w io.Writer, media []instagram.Media, properties ...string) error {
+	features, err := mediaFeatures(media, properties)
+	if err != nil {
+		return err
+	}
+	return writeKML(w, features, "caption")
+}
+
+// LocationsKML writes the locations as a KML document of placemarks named
+// after them, with the given properties as extended data, or
+// DefaultLocationProperties if none are given. The id of placemarks is
+// "location-" followed by the location ID. Locations without coordinates are
+// left out.
+func LocationsKML(w io.Writer, locations []instagram.Location, properties ...string) error {
+	features, err := locationFeatures(locations, properties)
+	if err != nil {
+		return err
+	}
+	return writeKML(w, features, "")
+}
+
+type kml struct {
+	XMLName  xml.Name    `xml:"http://www.opengis.net/kml/2.2 kml"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	ID           string        `xml:"id,attr,omitempty"`
+	Name         string        `xml:"name"`
+	Description  string        `xml:"description,omitempty"`
+	TimeStamp    *kmlTimeStamp `xml:"TimeStamp,omitempty"`
+	ExtendedData []kmlData     `xml:"ExtendedData>Data,omitempty"`
+	Point        kmlPoint      `xml:"Point"`
+}
+
+type kmlTimeStamp struct {
+	When string `xml:"when"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// writeKML writes features as KML, with the property named description, if
+// any, as description.
+func writeKML(w io.Writer, features []feature, description string) error {
+	doc := kml{}
+	for _, f := range features {
+		p := kmlPlacemark{
+			// XML IDs must not start with a digit, as media and location
+			// IDs do.
+			ID:   f.kind + "-" + f.id,
+			Name: f.name,
+			Point: kmlPoint{
+				Coordinates: strconv.FormatFloat(f.lng, 'f', -1, 64) + "," + strconv.FormatFloat(f.lat, 'f', -1, 64),
+			},
+		}
+		if f.created != 0 {
+			p.TimeStamp = &kmlTimeStamp{When: time.Unix(f.created, 0).UTC().Format(time.RFC3339)}
+		}
+		for i, name := range f.properties {
+			value := text(f.values[i])
+			if name == description {
+				p.Description = value
+			}
+			p.ExtendedData = append(p.ExtendedData, kmlData{Name: name, Value: value})
+		}
+		doc.Document.Placemarks = append(doc.Document.Placemarks, p)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
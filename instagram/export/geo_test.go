@@ -0,0 +1,123 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+func TestMediaGeoJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := MediaGeoJSON(&b, testMedia, "username", "likes"); err != nil {
+		t.Fatalf("MediaGeoJSON returned error: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"type": "FeatureCollection",
+		"features": []interface{}{
+			map[string]interface{}{
+				"type": "Feature",
+				"id":   "1_10",
+				"geometry": map[string]interface{}{
+					"type":        "Point",
+					"coordinates": []interface{}{2.35, 48.85},
+				},
+				"properties": map[string]interface{}{"username": "alice", "likes": 3.0},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MediaGeoJSON wrote %s", b.String())
+	}
+}
+
+func TestMediaGeoJSON_noCoordinates(t *testing.T) {
+	var b bytes.Buffer
+	media := []instagram.Media{
+		{ID: "3_10", Location: &instagram.MediaLocation{Name: "Somewhere"}},
+		{ID: "4_10", Location: &instagram.MediaLocation{Longitude: -0.5}},
+	}
+	if err := MediaGeoJSON(&b, media, "id"); err != nil {
+		t.Fatalf("MediaGeoJSON returned error: %v", err)
+	}
+	if strings.Contains(b.String(), "3_10") {
+		t.Errorf("MediaGeoJSON wrote media without coordinates:\n%s", b.String())
+	}
+	if !strings.Contains(b.String(), "4_10") {
+		t.Errorf("MediaGeoJSON left out media on the equator:\n%s", b.String())
+	}
+}
+
+func TestLocationsGeoJSON_empty(t *testing.T) {
+	var b bytes.Buffer
+	if err := LocationsGeoJSON(&b, nil); err != nil {
+		t.Fatalf("LocationsGeoJSON returned error: %v", err)
+	}
+	if !strings.Contains(b.String(), `"features": []`) {
+		t.Errorf("LocationsGeoJSON of no locations wrote %s", b.String())
+	}
+	if err := LocationsGeoJSON(&b, nil, "nope"); err == nil {
+		t.Errorf("LocationsGeoJSON with an unknown property returned no error")
+	}
+}
+
+func TestMediaKML(t *testing.T) {
+	var b bytes.Buffer
+	if err := MediaKML(&b, testMedia); err != nil {
+		t.Fatalf("MediaKML returned error: %v", err)
+	}
+
+	var doc kml
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not XML: %v\n%s", err, b.String())
+	}
+	if len(doc.Document.Placemarks) != 1 {
+		t.Fatalf("MediaKML wrote %d placemarks, want 1:\n%s", len(doc.Document.Placemarks), b.String())
+	}
+	p := doc.Document.Placemarks[0]
+	if p.ID != "media-1_10" || p.Name != "alice" || p.Point.Coordinates != "2.35,48.85" {
+		t.Errorf("placemark = %+v", p)
+	}
+	if p.Description != testMedia[0].Caption.Text {
+		t.Errorf("placemark description = %q, want the caption", p.Description)
+	}
+	if p.TimeStamp == nil || p.TimeStamp.When != "2013-09-24T05:20:00Z" {
+		t.Errorf("placemark time stamp = %+v", p.TimeStamp)
+	}
+	if len(p.ExtendedData) != len(DefaultMediaProperties) || p.ExtendedData[5].Value != "http://t/1.jpg" {
+		t.Errorf("placemark extended data = %+v", p.ExtendedData)
+	}
+}
+
+func TestLocationsKML(t *testing.T) {
+	var b bytes.Buffer
+	locations := []instagram.Location{{ID: "7", Name: "Paris & co", Latitude: 48.85, Longitude: 2.35}}
+	if err := LocationsKML(&b, locations, "name"); err != nil {
+		t.Fatalf("LocationsKML returned error: %v", err)
+	}
+	for _, want := range []string{
+		`<kml xmlns="http://www.opengis.net/kml/2.2">`,
+		`<Placemark id="location-7">`,
+		`<name>Paris &amp; co</name>`,
+		`<Data name="name">`,
+		`<coordinates>2.35,48.85</coordinates>`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("LocationsKML output does not contain %s:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CheckpointStore persists, for every object a MediaFetcher follows, the ID of
+// the newest media seen, or for tags the min_tag_id of the newest page, so
+// that fetching resumes where it left off. Keys are of the form
+// "object:objectID", e.g. "tag:nofilter".
+type CheckpointStore interface {
+	// Checkpoint returns the ID stored for key, or "" if there is none.
+	Checkpoint(key string) (string, error)
+
+	// SetCheckpoint stores id for key.
+	SetCheckpoint(key, id string) error
+}
+
+// MemoryCheckpointStore is a CheckpointStore keeping the checkpoints in memory.
+// The zero value is ready to use.
+type MemoryCheckpointStore struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+// Checkpoint returns the ID stored for key.
+func (s *MemoryCheckpointStore) Checkpoint(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m[key], nil
+}
+
+// SetCheckpoint stores id for key.
+func (s *MemoryCheckpointStore) SetCheckpoint(key, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.m == nil {
+		s.m = make(map[string]string)
+	}
+	s.m[key] = id
+	return nil
+}
+
+// MediaFetcher turns realtime notifications, which only tell that an object
+// changed, into the new media of that object. It calls the RecentMedia
+// endpoint of the object with the MinID recorded in its CheckpointStore and
+// advances the checkpoint once the new media are fetched.
+//
+// Its Notify method can be used directly as a RealtimeHandler callback:
+//
+//	f := &instagram.MediaFetcher{
+//		Client:  client,
+//		OnMedia: func(object, objectID string, media []instagram.Media) { ... },
+//	}
+//	handler := &instagram.RealtimeHandler{OnTag: f.Notify, ...}
+//
+// Notifications for the same object arriving while a fetch is scheduled are
+// coalesced into it, and those arriving while a fetch runs cause a single
+// further fetch. Close cancels the fetches started by Notify. A MediaFetcher
+// is safe for concurrent use.
+type MediaFetcher struct {
+	Client *Client
+
+	// Store holds the checkpoints. An in-memory store is used when nil.
+	Store CheckpointStore
+
+	// Delay is how long Notify waits before fetching, so that a burst of
+	// notifications about the same object results in a single fetch.
+	Delay time.Duration
+
+	// MaxPages limits the number of pages fetched per object and fetch. Zero
+	// means no limit. Objects without checkpoint always get a single page
+	// rather than their whole history.
+	MaxPages int
+
+	// OnMedia is called by Notify with the new media of an object, newest
+	// first. It is not called when there are none.
+	OnMedia func(object, objectID string, media []Media)
+
+	// OnError, if set, is called when a fetch started by Notify fails.
+	OnError func(object, objectID string, err error)
+
+	mu      sync.Mutex
+	store   CheckpointStore
+	pending map[string]*fetchState
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closed  bool
+}
+
+// fetchState tracks the fetch scheduled or running for an object.
+type fetchState struct {
+	running bool
+	again   bool
+}
+
+// CheckpointKey returns the key under which the checkpoint of an object is
+// stored.
+func CheckpointKey(object, objectID string) string {
+	return object + ":" + objectID
+}
+
+func (f *MediaFetcher) checkpoints() CheckpointStore {
+	if f.Store != nil {
+		return f.Store
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.store == nil {
+		f.store = new(MemoryCheckpointStore)
+	}
+	return f.store
+}
+
+// Notify schedules a fetch of the new media of the object n is about. It
+// returns immediately; the media are passed to OnMedia.
+func (f *MediaFetcher) Notify(n RealtimeResponse) {
+	key := CheckpointKey(n.Object, n.ObjectID)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+	if st, ok := f.pending[key]; ok {
+		if st.running {
+			st.again = true
+		}
+		return
+	}
+
+	if f.pending == nil {
+		f.pending = make(map[string]*fetchState)
+	}
+	st := new(fetchState)
+	f.pending[key] = st
+	if f.ctx == nil {
+		f.ctx, f.cancel = context.WithCancel(context.Background())
+	}
+	ctx := f.ctx
+
+	f.wg.Add(1)
+	time.AfterFunc(f.Delay, func() {
+		defer f.wg.Done()
+		f.run(ctx, key, n.Object, n.ObjectID, st)
+	})
+}
+
+// run fetches the new media of an object until no notification arrived during
+// the last fetch, or ctx is done.
+func (f *MediaFetcher) run(ctx context.Context, key, object, objectID string, st *fetchState) {
+	for {
+		f.mu.Lock()
+		st.running, st.again = true, false
+		f.mu.Unlock()
+
+		media, err := f.Fetch(ctx, object, objectID)
+		if ctx.Err() != nil {
+			f.mu.Lock()
+			delete(f.pending, key)
+			f.mu.Unlock()
+			return
+		}
+		if err != nil {
+			if f.OnError != nil {
+				f.OnError(object, objectID, err)
+			}
+		} else if len(media) > 0 && f.OnMedia != nil {
+			f.OnMedia(object, objectID, media)
+		}
+
+		f.mu.Lock()
+		if !st.again {
+			delete(f.pending, key)
+			f.mu.Unlock()
+			return
+		}
+		f.mu.Unlock()
+	}
+}
+
+// Wait blocks until the fetches scheduled by Notify are done.
+func (f *MediaFetcher) Wait() {
+	f.wg.Wait()
+}
+
+// Close cancels the fetches scheduled by Notify and waits for them to return.
+// Later notifications are ignored.
+func (f *MediaFetcher) Close() {
+	f.mu.Lock()
+	f.closed = true
+	if f.cancel != nil {
+		f.cancel()
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+}
+
+// Fetch returns the media of an object published since the last call for that
+// object, newest first, and advances its checkpoint. object is one of
+// ObjectTag, ObjectLocation, ObjectGeography or ObjectUser.
+func (f *MediaFetcher) Fetch(ctx context.Context, object, objectID string) ([]Media, error) {
+	media, _, err := f.fetch(ctx, object, objectID, time.Time{})
+	return media, err
+}
+
+// fetch is like Fetch but also returns the response of the last page fetched.
+// Objects without checkpoint get the media created since the given time, if
+// not zero, from the endpoints supporting min_timestamp.
+func (f *MediaFetcher) fetch(ctx context.Context, object, objectID string, since time.Time) ([]Media, *Response, error) {
+	store := f.checkpoints()
+	key := CheckpointKey(object, objectID)
+
+	checkpoint, err := store.Checkpoint(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Tag media are paginated by tag IDs, which the checkpoint of tags is.
+	opt := new(Parameters)
+	if object == ObjectTag {
+		opt.MinTagID = checkpoint
+	} else {
+		opt.MinID = checkpoint
+	}
+	if checkpoint == "" && !since.IsZero() && (object == ObjectUser || object == ObjectLocation) {
+		opt.MinTimestamp = since.Unix()
+	}
+	it, err := f.iterator(object, objectID, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	it.MaxPages = f.MaxPages
+	if checkpoint == "" {
+		it.MaxPages = 1
+	} else if object != ObjectTag {
+		// min_id is not always honoured; never go past the checkpoint.
+		it.Stop = func(m *Media) bool { return m.ID == checkpoint }
+	}
+
+	var media []Media
+	var first *Response
+	seen := make(map[string]bool)
+	for it.Next(ctx) {
+		if first == nil {
+			first = it.Response()
+		}
+		m := it.Media()
+		if seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+		media = append(media, *m)
+	}
+	if err := it.Err(); err != nil {
+		return nil, it.Response(), err
+	}
+
+	next := ""
+	if object == ObjectTag {
+		if first != nil && first.Pagination != nil {
+			next = first.Pagination.MinTagID
+		}
+	} else if len(media) > 0 {
+		next = media[0].ID
+	}
+	if next != "" {
+		if err := store.SetCheckpoint(key, next); err != nil {
+			return nil, it.Response(), err
+		}
+	}
+
+	return media, it.Response(), nil
+}
+
+// iterator returns an iterator over the recent media of an object.
+func (f *MediaFetcher) iterator(object, objectID string, opt *Parameters) (*MediaIterator, error) {
+	switch object {
+	case ObjectTag:
+		return f.Client.Tags.RecentMediaIterator(objectID, opt), nil
+	case ObjectLocation:
+		return f.Client.Locations.RecentMediaIterator(objectID, opt), nil
+	case ObjectGeography:
+		return f.Client.Geographies.RecentMediaIterator(objectID, opt), nil
+	case ObjectUser:
+		return f.Client.Users.RecentMediaIterator(objectID, opt), nil
+	}
+	return nil, fmt.Errorf("instagram: cannot fetch media of %q objects", object)
+}
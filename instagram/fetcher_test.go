@@ -0,0 +1,177 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ids(media []Media) []string {
+	var ids []string
+	for _, m := range media {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func TestMediaFetcher_Fetch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/locations/1/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("min_id") {
+		case "":
+			fmt.Fprint(w, `{"data": [{"id":"3"}, {"id":"2"}], "pagination": {"next_max_id": "2"}}`)
+		case "3":
+			// The checkpoint itself is returned as well.
+			fmt.Fprint(w, `{"data": [{"id":"5"}, {"id":"4"}, {"id":"3"}]}`)
+		case "5":
+			fmt.Fprint(w, `{"data": []}`)
+		default:
+			t.Errorf("unexpected min_id %q", r.FormValue("min_id"))
+		}
+	})
+
+	store := new(MemoryCheckpointStore)
+	f := &MediaFetcher{Client: client, Store: store}
+
+	for _, want := range [][]string{{"3", "2"}, {"5", "4"}, nil} {
+		media, err := f.Fetch(context.Background(), ObjectLocation, "1")
+		if err != nil {
+			t.Fatalf("Fetch returned error: %v", err)
+		}
+		if got := ids(media); !reflect.DeepEqual(got, want) {
+			t.Errorf("Fetch returned %v, want %v", got, want)
+		}
+	}
+
+	if id, _ := store.Checkpoint("location:1"); id != "5" {
+		t.Errorf("checkpoint is %q, want %q", id, "5")
+	}
+
+	if _, err := f.Fetch(context.Background(), "media", "1"); err == nil {
+		t.Errorf("Fetch of a media object returned no error")
+	}
+}
+
+func TestMediaFetcher_Fetch_tag(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/tags/t/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("min_id") != "" {
+			t.Errorf("tag media requested with min_id %q", r.FormValue("min_id"))
+		}
+		switch r.FormValue("min_tag_id") {
+		case "":
+			fmt.Fprint(w, `{"data": [{"id":"3"}, {"id":"2"}], "pagination": {"min_tag_id": "30", "next_max_tag_id": "20"}}`)
+		case "30":
+			fmt.Fprint(w, `{"data": [{"id":"5"}, {"id":"4"}], "pagination": {"min_tag_id": "50"}}`)
+		case "50":
+			fmt.Fprint(w, `{"data": []}`)
+		default:
+			t.Errorf("unexpected min_tag_id %q", r.FormValue("min_tag_id"))
+		}
+	})
+
+	store := new(MemoryCheckpointStore)
+	f := &MediaFetcher{Client: client, Store: store}
+
+	for _, want := range [][]string{{"3", "2"}, {"5", "4"}, nil} {
+		media, err := f.Fetch(context.Background(), ObjectTag, "t")
+		if err != nil {
+			t.Fatalf("Fetch returned error: %v", err)
+		}
+		if got := ids(media); !reflect.DeepEqual(got, want) {
+			t.Errorf("Fetch returned %v, want %v", got, want)
+		}
+	}
+
+	if id, _ := store.Checkpoint("tag:t"); id != "50" {
+		t.Errorf("checkpoint is %q, want %q", id, "50")
+	}
+}
+
+func TestMediaFetcher_Notify(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var mu sync.Mutex
+	calls := 0
+	mux.HandleFunc("/locations/1/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		fmt.Fprint(w, `{"data": [{"id":"1"}]}`)
+	})
+
+	var got [][]string
+	f := &MediaFetcher{
+		Client: client,
+		Delay:  50 * time.Millisecond,
+		OnMedia: func(object, objectID string, media []Media) {
+			if object != ObjectLocation || objectID != "1" {
+				t.Errorf("OnMedia called for %s %s", object, objectID)
+			}
+			got = append(got, ids(media))
+		},
+	}
+
+	n := RealtimeResponse{Object: ObjectLocation, ObjectID: "1"}
+	for i := 0; i < 3; i++ {
+		f.Notify(n)
+	}
+	f.Wait()
+
+	if calls != 1 {
+		t.Errorf("Notify fetched %d times, want 1", calls)
+	}
+	if want := [][]string{{"1"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OnMedia received %v, want %v", got, want)
+	}
+}
+
+func TestMediaFetcher_Close(t *testing.T) {
+	setup()
+	defer teardown()
+
+	started := make(chan bool)
+	mux.HandleFunc("/locations/1/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		started <- true
+		<-r.Context().Done()
+	})
+
+	var failed error
+	f := &MediaFetcher{
+		Client:  client,
+		OnError: func(object, objectID string, err error) { failed = err },
+	}
+	f.Notify(RealtimeResponse{Object: ObjectLocation, ObjectID: "1"})
+	<-started
+
+	done := make(chan bool)
+	go func() {
+		f.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not cancel the running fetch")
+	}
+	if failed != nil {
+		t.Errorf("OnError called with %v after Close", failed)
+	}
+
+	f.Notify(RealtimeResponse{Object: ObjectLocation, ObjectID: "1"})
+	f.Wait()
+}
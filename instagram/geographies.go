@@ -6,6 +6,7 @@
 package instagram
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -24,33 +25,41 @@ type GeographiesService struct {
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/geographies/#get_geographies_media_recent
 func (s *GeographiesService) RecentMedia(geoID string, opt *Parameters) ([]Media, *ResponsePagination, error) {
+	media, resp, err := s.RecentMediaContext(context.Background(), geoID, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, resp.Pagination, nil
+}
+
+// RecentMediaContext is like RecentMedia but stops when ctx is done and returns
+// the Response.
+func (s *GeographiesService) RecentMediaContext(ctx context.Context, geoID string, opt *Parameters) ([]Media, *Response, error) {
 	u := fmt.Sprintf("geographies/%v/media/recent", geoID)
 	if opt != nil {
 		params := url.Values{}
 		if opt.MinID != "" {
 			params.Add("min_id", opt.MinID)
 		}
+		if opt.MaxID != "" {
+			params.Add("max_id", opt.MaxID)
+		}
 		if opt.Count != 0 {
 			params.Add("count", strconv.FormatUint(opt.Count, 10))
 		}
 		u += "?" + params.Encode()
 	}
 
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	media := new([]Media)
-	_, err = s.client.Do(req, media)
+	resp, err := s.client.DoContext(ctx, req, media)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
+		return nil, resp, err
 	}
 
-	return *media, page, err
+	return *media, resp, err
 }
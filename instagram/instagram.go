@@ -33,16 +33,20 @@ package instagram
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -88,19 +92,25 @@ type Client struct {
 	// Signing should be enabled on instagram API account config.
 	SignedRequests bool
 
+	// RateLimiter, if set, delays requests to stay within the rate limits
+	// reported by Instagram.
+	RateLimiter *RateLimiter
+
+	// Retry, if set, retries requests failing with a transient error.
+	Retry *RetryPolicy
+
 	// Services used for talking to different parts of the API.
-	Users         *UsersService
-	Relationships *RelationshipsService
-	Media         *MediaService
-	Comments      *CommentsService
-	Likes         *LikesService
-	Tags          *TagsService
-	Locations     *LocationsService
-	Geographies   *GeographiesService
-	Realtime      *RealtimeService
-
-	// Temporary Response
-	Response *Response
+	// They are set to the *Service types by NewClient and may be replaced,
+	// e.g. by mocks in tests.
+	Users         UsersAPI
+	Relationships RelationshipsAPI
+	Media         MediaAPI
+	Comments      CommentsAPI
+	Likes         LikesAPI
+	Tags          TagsAPI
+	Locations     LocationsAPI
+	Geographies   GeographiesAPI
+	Realtime      RealtimeAPI
 }
 
 // Parameters specifies the optional parameters to various service's methods.
@@ -109,6 +119,8 @@ type Parameters struct {
 	Cursor       string
 	MinID        string
 	MaxID        string
+	MinTagID     string // min_tag_id, for tag media only
+	MaxTagID     string // max_tag_id, for tag media only
 	MinTimestamp int64
 	MaxTimestamp int64
 	Lat          float64
@@ -125,7 +137,9 @@ type Ratelimit struct {
 	Remaining int
 }
 
-// Response specifies Instagram's response structure.
+// Response specifies Instagram's response structure. A Response is returned by
+// every call made through the Context methods of the services; it belongs to
+// that call only, so a Client can be shared between goroutines.
 //
 // Instagram's envelope structure spec: http://instagram.com/developer/endpoints/#structure
 type Response struct {
@@ -150,7 +164,7 @@ func (r *Response) GetData() interface{} {
 // GetError gets error from meta's response.
 func (r *Response) GetError() error {
 	if r.Meta.ErrorType != "" || r.Meta.ErrorMessage != "" {
-		return fmt.Errorf(fmt.Sprintf("%s: %s", r.Meta.ErrorType, r.Meta.ErrorMessage))
+		return fmt.Errorf("%s: %s", r.Meta.ErrorType, r.Meta.ErrorMessage)
 	}
 	return nil
 }
@@ -169,6 +183,10 @@ func (r *Response) GetRatelimit() (Ratelimit, error) {
 		Remaining = `X-Ratelimit-Remaining`
 	)
 
+	if r.Response == nil {
+		return rl, errors.New("instagram: no HTTP response to read rate limit from")
+	}
+
 	rl.Limit, err = strconv.Atoi(r.Response.Header.Get(Limit))
 	if err != nil {
 		return rl, err
@@ -190,6 +208,13 @@ func (r *Response) NextMaxID() string {
 	return p.NextMaxID
 }
 
+// NextMaxTagID gets MaxTagID parameter that can be passed for next request of
+// tag media.
+func (r *Response) NextMaxTagID() string {
+	p := r.GetPagination()
+	return p.NextMaxTagID
+}
+
 // Cursor gets Cursor parameter that can be passed for next request.
 func (r *Response) Cursor() string {
 	p := r.GetPagination()
@@ -203,14 +228,21 @@ type ResponseMeta struct {
 	ErrorType    string `json:"error_type,omitempty"`
 	Code         int    `json:"code,omitempty"`
 	ErrorMessage string `json:"error_message,omitempty"`
+
+	// StatusCode is the HTTP status of the response. It is only set on the
+	// errors returned by CheckResponse.
+	StatusCode int `json:"-"`
 }
 
 // ResponsePagination represents information to get access to more data in
 // any request for sequential data.
 type ResponsePagination struct {
-	NextURL   string `json:"next_url,omitempty"`
-	NextMaxID string `json:"next_max_id,omitempty"`
-	Cursor    string `json:"next_cursor,omitempty"`
+	NextURL       string `json:"next_url,omitempty"`
+	NextMaxID     string `json:"next_max_id,omitempty"`
+	NextMaxLikeID string `json:"next_max_like_id,omitempty"`
+	NextMaxTagID  string `json:"next_max_tag_id,omitempty"`
+	MinTagID      string `json:"min_tag_id,omitempty"`
+	Cursor        string `json:"next_cursor,omitempty"`
 }
 
 // NewClient returns a new Instagram API client. if a nil httpClient is
@@ -280,6 +312,12 @@ func (c *Client) GenerateSignature(endpoint string, params url.Values) string {
 // Relative URLs should always be specified without a preceding slash. If
 // specified
 func (c *Client) NewRequest(method, urlStr string, body string) (*http.Request, error) {
+	return c.NewRequestWithContext(context.Background(), method, urlStr, body)
+}
+
+// NewRequestWithContext is like NewRequest but the returned request carries
+// ctx, so that cancelling ctx aborts it once passed to Do.
+func (c *Client) NewRequestWithContext(ctx context.Context, method, urlStr string, body string) (*http.Request, error) {
 	rel, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, err
@@ -303,7 +341,7 @@ func (c *Client) NewRequest(method, urlStr string, body string) (*http.Request,
 
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequest(method, u.String(), bytes.NewBufferString(body))
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewBufferString(body))
 	if err != nil {
 		return nil, err
 	}
@@ -324,25 +362,91 @@ func (c *Client) NewRequest(method, urlStr string, body string) (*http.Request,
 // decoded and stored in the value pointed to by v, or returned as an error if
 // an API error has occurred.
 func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
-	resp, err := c.client.Do(req)
+	r, err := c.DoContext(req.Context(), req, v)
+	if r == nil {
+		return nil, err
+	}
+	return r.Response, err
+}
+
+// DoContext is like Do but sends the request with ctx and returns the whole
+// Response, including its pagination and the underlying http.Response. If ctx
+// is cancelled or its deadline passes before the response arrives, ctx's
+// error is returned.
+func (c *Client) DoContext(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
+	resp, err := c.send(ctx, req)
 	if err != nil {
+		// Prefer the context's error, which is more useful than the
+		// transport's wrapped one.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, err
 	}
 
 	//defer resp.Body.Close() this is so dumb
 
+	r := &Response{Response: resp}
+
 	err = CheckResponse(resp)
 	if err != nil {
-		return resp, err
+		return r, err
 	}
 
-	r := &Response{Response: resp}
 	if v != nil {
 		r.Data = v
 		err = json.NewDecoder(resp.Body).Decode(r)
-		c.Response = r
 	}
-	return resp, err
+	if r.Pagination == nil {
+		r.Pagination = new(ResponsePagination)
+	}
+	return r, err
+}
+
+// send sends req, pacing it with the client's RateLimiter and retrying it
+// according to its Retry policy.
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	var key string
+	if c.RateLimiter != nil {
+		key = ratelimitKey(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if c.RateLimiter != nil {
+			if err := c.RateLimiter.Wait(ctx, key); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.client.Do(req.WithContext(ctx))
+		if err == nil && c.RateLimiter != nil {
+			if rl, err := (&Response{Response: resp}).GetRatelimit(); err == nil {
+				c.RateLimiter.Update(key, rl)
+			}
+		}
+
+		if ctx.Err() != nil || !c.Retry.retry(attempt, req, resp, err) {
+			return resp, err
+		}
+
+		delay := c.Retry.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
 }
 
 // Error represents an error recieved from instagram
@@ -353,6 +457,80 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s (%d): %s", err.ErrorType, err.Code, err.ErrorMessage)
 }
 
+// Kinds of errors returned by the API. They can be tested with errors.Is on
+// the *Error or *ErrorResponse values returned by the client:
+//
+//	if errors.Is(err, instagram.ErrInvalidToken) {
+//		// ask the user to authorize again
+//	}
+//
+// Use errors.As to get at the status code and message of the *Error.
+var (
+	// ErrInvalidToken means the access token is invalid, expired or revoked.
+	ErrInvalidToken = errors.New("instagram: invalid access token")
+
+	// ErrRateLimited means the rate limit of the token or client is exhausted.
+	ErrRateLimited = errors.New("instagram: rate limit exceeded")
+
+	// ErrPrivateUser means the resource belongs to a private user who is not
+	// followed by the authenticated user.
+	ErrPrivateUser = errors.New("instagram: private user")
+
+	// ErrNotFound means the user, media, tag or location does not exist.
+	ErrNotFound = errors.New("instagram: not found")
+
+	// ErrInsufficientScope means the access token lacks the scope required by
+	// the endpoint.
+	ErrInsufficientScope = errors.New("instagram: insufficient scope")
+
+	// ErrServer means Instagram failed to handle the request (5xx).
+	ErrServer = errors.New("instagram: server error")
+)
+
+// Kind returns which of the ErrInvalidToken, ErrRateLimited, ErrPrivateUser,
+// ErrNotFound, ErrInsufficientScope and ErrServer errors err is, deduced from
+// its error type and HTTP status. It returns nil for other errors.
+func (err *Error) Kind() error {
+	switch err.ErrorType {
+	case "OAuthAccessTokenException":
+		return ErrInvalidToken
+	case "OAuthParameterException":
+		// Also used for malformed parameters; only a bad token is of
+		// interest here.
+		if strings.Contains(err.ErrorMessage, "access_token") {
+			return ErrInvalidToken
+		}
+	case "OAuthRateLimitException":
+		return ErrRateLimited
+	case "APINotAllowedError":
+		return ErrPrivateUser
+	case "APINotFoundError":
+		return ErrNotFound
+	case "OAuthPermissionsException":
+		return ErrInsufficientScope
+	}
+
+	status := err.StatusCode
+	if status == 0 {
+		status = err.Code
+	}
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status >= http.StatusInternalServerError:
+		return ErrServer
+	}
+	return nil
+}
+
+// Is reports whether err is of the kind target, for use by errors.Is.
+func (err *Error) Is(target error) bool {
+	kind := err.Kind()
+	return kind != nil && kind == target
+}
+
 // ErrorResponse represents a Response which contains an error
 type ErrorResponse Response
 
@@ -362,7 +540,7 @@ func (r *ErrorResponse) Error() string {
 	}
 
 	if r.Response == nil || r.Response.Request == nil {
-		return fmt.Sprintf("A nil error response was returned on %v", r)
+		return fmt.Sprintf("A nil error response was returned on %+v", r.Meta)
 	}
 
 	if r.Response.Request.URL == nil {
@@ -379,6 +557,20 @@ func (r *ErrorResponse) Error() string {
 		r.Response.StatusCode, r.Meta.ErrorType, r.Meta.ErrorMessage)
 }
 
+// Is reports whether the error in r's metadata is of the kind target, for use
+// by errors.Is.
+func (r *ErrorResponse) Is(target error) bool {
+	if r == nil || r.Meta == nil {
+		return false
+	}
+
+	err := Error(*r.Meta)
+	if err.StatusCode == 0 && r.Response != nil {
+		err.StatusCode = r.Response.StatusCode
+	}
+	return err.Is(target)
+}
+
 // CheckResponse checks the API response for error, and returns it
 // if present. A response is considered an error if it has non StatusOK
 // code.
@@ -392,54 +584,42 @@ func CheckResponse(r *http.Response) error {
 		return readErr
 	}
 
-	// Forbidden: see http://instagram.com/developer/restrict-api-requests/
-	if r.StatusCode == http.StatusForbidden {
-		err := &Error{}
-		json.Unmarshal(data, &err)
-		return err
-	}
-
-	// RateLimit: see http://instagram.com/developer/limits/
-	if r.StatusCode == 429 {
-		err := &Error{}
-		json.Unmarshal(data, &err)
-		return err
-	}
+	err := parseError(r.StatusCode, data)
+	err.StatusCode = r.StatusCode
+	return err
+}
 
+// parseError builds the Error described by the body of an unsuccessful
+// response.
+func parseError(status int, data []byte) *Error {
 	// Sometimes Instagram returns 500 with plain message
 	// "Oops, an error occurred.".
-	if r.StatusCode == http.StatusInternalServerError {
-		err := &Error{
+	if status == http.StatusInternalServerError {
+		return &Error{
 			ErrorType:    "Internal Server Error",
 			Code:         http.StatusInternalServerError,
 			ErrorMessage: "Oops, an error occurred.",
 		}
-		return err
-	}
-
-	if data != nil {
-		// Unlike for successful (2XX) requests, unsuccessful
-		// requests SOMETIMES have the {Meta: Error{}} format but
-		// SOMETIMES they are just Error{}. From what I can tell, there is not
-		// an obvious rationale behind what gets constructed in which way, so
-		// we need to try both:
-		err := &Error{}
-		json.Unmarshal(data, err)
-		if *err == *new(Error) {
-			// Unmarshaling did nothing for us, so the format was not Error{}.
-			// We will assume the format was {Meta: Error{}}:
-			temp := make(map[string]Error)
-			json.Unmarshal(data, &temp)
-
-			meta := temp["meta"]
-
-			delete(temp, "meta") // Probably uselesss
-			return &meta
-		}
+	}
 
-		// Unmarshaling did something
-		return err
+	// Unlike for successful (2XX) requests, unsuccessful requests, including
+	// Forbidden (see http://instagram.com/developer/restrict-api-requests/)
+	// and rate limited ones (see http://instagram.com/developer/limits/),
+	// SOMETIMES have the {Meta: Error{}} format but SOMETIMES they are just
+	// Error{}. From what I can tell, there is not an obvious rationale behind
+	// what gets constructed in which way, so we need to try both:
+	err := &Error{}
+	json.Unmarshal(data, err)
+	if *err == *new(Error) {
+		// Unmarshaling did nothing for us, so the format was not Error{}.
+		// We will assume the format was {Meta: Error{}}:
+		temp := make(map[string]Error)
+		json.Unmarshal(data, &temp)
+
+		meta := temp["meta"]
+		return &meta
 	}
 
-	return nil
+	// Unmarshaling did something
+	return err
 }
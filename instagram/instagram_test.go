@@ -6,9 +6,16 @@
 package instagram
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -88,3 +95,125 @@ func TestNewRequest(t *testing.T) {
 		t.Errorf("NewRequest() User-Agent = %v, want %v", userAgent, c.UserAgent)
 	}
 }
+
+func TestNewRequestWithContext(t *testing.T) {
+	c := NewClient(nil)
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "v")
+	req, _ := c.NewRequestWithContext(ctx, "GET", "foo", "")
+
+	if req.Context() != ctx {
+		t.Errorf("NewRequestWithContext() request context = %v, want %v", req.Context(), ctx)
+	}
+}
+
+func TestDoContext_cancelled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/self", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent despite cancelled context")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := client.Users.GetContext(ctx, "")
+	if err != context.Canceled {
+		t.Errorf("Users.GetContext returned error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestDoContext_response(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/self/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Limit", "5000")
+		w.Header().Set("X-Ratelimit-Remaining", "4999")
+		fmt.Fprint(w, `{"data": [], "pagination": {"next_max_id": "2"}}`)
+	})
+
+	_, resp, err := client.Users.MediaFeedContext(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Users.MediaFeedContext returned error: %v", err)
+	}
+
+	if want := (&ResponsePagination{NextMaxID: "2"}); !reflect.DeepEqual(resp.Pagination, want) {
+		t.Errorf("Response.Pagination = %+v, want %+v", resp.Pagination, want)
+	}
+
+	rl, err := resp.GetRatelimit()
+	if err != nil {
+		t.Errorf("Response.GetRatelimit returned error: %v", err)
+	}
+	if want := (Ratelimit{Limit: 5000, Remaining: 4999}); rl != want {
+		t.Errorf("Response.GetRatelimit returned %+v, want %+v", rl, want)
+	}
+}
+
+func TestDoContext_concurrentPagination(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/tags/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": [], "pagination": {"next_max_id": %q}}`, r.URL.Path)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(tag string) {
+			defer wg.Done()
+
+			_, page, err := client.Tags.RecentMedia(tag, nil)
+			if err != nil {
+				t.Errorf("Tags.RecentMedia returned error: %v", err)
+				return
+			}
+			if want := "/tags/" + tag + "/media/recent"; page.NextMaxID != want {
+				t.Errorf("Tags.RecentMedia(%q) pagination NextMaxID = %v, want %v", tag, page.NextMaxID, want)
+			}
+		}(fmt.Sprintf("t%d", i))
+	}
+	wg.Wait()
+}
+
+func TestCheckResponse_kinds(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{400, `{"meta": {"code": 400, "error_type": "OAuthAccessTokenException", "error_message": "The access_token provided is invalid."}}`, ErrInvalidToken},
+		{400, `{"code": 400, "error_type": "OAuthParameterException", "error_message": "Missing access_token URL parameter."}`, ErrInvalidToken},
+		{400, `{"code": 400, "error_type": "OAuthParameterException", "error_message": "Invalid count."}`, nil},
+		{429, `{"code": 429, "error_type": "OAuthRateLimitException", "error_message": "The maximum number of requests per hour has been exceeded."}`, ErrRateLimited},
+		{429, ``, ErrRateLimited},
+		{400, `{"meta": {"code": 400, "error_type": "APINotAllowedError", "error_message": "you cannot view this resource"}}`, ErrPrivateUser},
+		{403, `{"meta": {"code": 403, "error_type": "APINotAllowedError", "error_message": "you cannot view this resource"}}`, ErrPrivateUser},
+		{400, `{"meta": {"code": 400, "error_type": "APINotFoundError", "error_message": "this user does not exist"}}`, ErrNotFound},
+		{404, `<html>Not Found</html>`, ErrNotFound},
+		{400, `{"meta": {"code": 400, "error_type": "OAuthPermissionsException", "error_message": "This request requires scope=likes"}}`, ErrInsufficientScope},
+		{500, `Oops, an error occurred.`, ErrServer},
+		{502, `<html>Bad Gateway</html>`, ErrServer},
+	}
+
+	kinds := []error{ErrInvalidToken, ErrRateLimited, ErrPrivateUser, ErrNotFound, ErrInsufficientScope, ErrServer}
+	for _, tt := range tests {
+		r := &http.Response{StatusCode: tt.status, Body: ioutil.NopCloser(strings.NewReader(tt.body))}
+		err := CheckResponse(r)
+
+		var e *Error
+		if !errors.As(err, &e) || e.StatusCode != tt.status {
+			t.Errorf("CheckResponse(%d, %s) = %#v, want *Error with StatusCode %d", tt.status, tt.body, err, tt.status)
+			continue
+		}
+		for _, kind := range kinds {
+			if got := errors.Is(err, kind); got != (kind == tt.want) {
+				t.Errorf("errors.Is(CheckResponse(%d, %s), %v) = %v", tt.status, tt.body, kind, got)
+			}
+		}
+	}
+}
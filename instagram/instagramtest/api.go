@@ -0,0 +1,820 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagramtest
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+// route dispatches an API request, split into path segments, to its handler.
+// s.mu is held.
+func (s *Server) route(r *http.Request, viewer string, path []string) (*page, error) {
+	method := r.Method
+	match := func(m string, pattern ...string) bool {
+		if m != method || len(pattern) != len(path) {
+			return false
+		}
+		for i, p := range pattern {
+			if p != "*" && p != path[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case match("GET", "users", "search"):
+		return s.searchUsers(r)
+	case match("GET", "users", "self", "feed"):
+		return s.feed(r, viewer)
+	case match("GET", "users", "self", "media", "liked"):
+		return s.liked(r, viewer)
+	case match("GET", "users", "self", "requested-by"):
+		return s.requestedBy(r, viewer)
+	case match("GET", "users", "*"):
+		return s.getUser(viewer, path[1])
+	case match("GET", "users", "*", "media", "recent"):
+		return s.userMedia(r, viewer, path[1])
+	case match("GET", "users", "*", "follows"):
+		return s.follows(r, viewer, path[1], false)
+	case match("GET", "users", "*", "followed-by"):
+		return s.follows(r, viewer, path[1], true)
+	case match("GET", "users", "*", "relationship"):
+		return s.relationship(viewer, path[1], "")
+	case match("POST", "users", "*", "relationship"):
+		return s.relationship(viewer, path[1], r.FormValue("action"))
+
+	case match("GET", "media", "search"):
+		return s.searchMedia(r, viewer)
+	case match("GET", "media", "popular"):
+		return s.popular(viewer)
+	case match("GET", "media", "shortcode", "*"):
+		return s.getMedia(viewer, "", path[2])
+	case match("GET", "media", "*"):
+		return s.getMedia(viewer, path[1], "")
+	case match("GET", "media", "*", "comments"):
+		return s.comments(viewer, path[1])
+	case match("POST", "media", "*", "comments"):
+		return s.addComment(viewer, path[1], r.FormValue("text"))
+	case match("DELETE", "media", "*", "comments", "*"):
+		return s.deleteComment(viewer, path[1], path[3])
+	case match("GET", "media", "*", "likes"):
+		return s.likes(viewer, path[1])
+	case match("POST", "media", "*", "likes"):
+		return s.like(viewer, path[1], true)
+	case match("DELETE", "media", "*", "likes"):
+		return s.like(viewer, path[1], false)
+
+	case match("GET", "tags", "search"):
+		return s.searchTags(r)
+	case match("GET", "tags", "*"):
+		return s.getTag(path[1])
+	case match("GET", "tags", "*", "media", "recent"):
+		tag := strings.ToLower(path[1])
+		return s.paginateTagMedia(r, viewer, s.visibleMedia(viewer, func(m *instagram.Media) bool {
+			return contains(m.Tags, tag)
+		}))
+
+	case match("GET", "locations", "search"):
+		return s.searchLocations(r)
+	case match("GET", "locations", "*"):
+		l, ok := s.locations[path[1]]
+		if !ok {
+			return nil, notFound("invalid location id")
+		}
+		return &page{data: l}, nil
+	case match("GET", "locations", "*", "media", "recent"):
+		if _, ok := s.locations[path[1]]; !ok {
+			return nil, notFound("invalid location id")
+		}
+		return s.paginateMedia(r, viewer, s.visibleMedia(viewer, func(m *instagram.Media) bool {
+			return m.Location != nil && strconv.Itoa(m.Location.ID) == path[1]
+		}))
+	}
+
+	return nil, errNoEndpoint
+}
+
+// lookupUser returns the user designated by id, "self" being the viewer.
+func (s *Server) lookupUser(viewer, id string) (*userRecord, error) {
+	if id == "self" {
+		if viewer == "" {
+			return nil, errSelfToken
+		}
+		id = viewer
+	}
+	rec, ok := s.users[id]
+	if !ok {
+		return nil, notFound("this user does not exist")
+	}
+	return rec, nil
+}
+
+// self returns the viewer, for endpoints needing an access token.
+func (s *Server) self(viewer string) (*userRecord, error) {
+	return s.lookupUser(viewer, "self")
+}
+
+// canView reports whether the media and relationships of u are visible to
+// viewer.
+func (s *Server) canView(viewer string, u *userRecord) bool {
+	if !u.private || viewer == u.user.ID {
+		return true
+	}
+	v, ok := s.users[viewer]
+	return ok && v.follows[u.user.ID]
+}
+
+func (s *Server) renderUser(u *userRecord) instagram.User {
+	user := u.user
+	counts := &instagram.UserCount{Follows: len(u.follows)}
+	for _, m := range s.media {
+		if m.media.User.ID == user.ID {
+			counts.Media++
+		}
+	}
+	for _, other := range s.users {
+		if other.follows[user.ID] {
+			counts.FollowedBy++
+		}
+	}
+	user.Counts = counts
+	return user
+}
+
+// shortUser returns the user as nested in media, comments and likes.
+func shortUser(u *userRecord) *instagram.User {
+	return &instagram.User{
+		ID:             u.user.ID,
+		Username:       u.user.Username,
+		FullName:       u.user.FullName,
+		ProfilePicture: u.user.ProfilePicture,
+	}
+}
+
+func (s *Server) renderMedia(m *mediaRecord, viewer string) instagram.Media {
+	media := m.media
+
+	media.Comments = &instagram.MediaComments{Count: len(m.comments)}
+	for i := range m.comments {
+		media.Comments.Data = append(media.Comments.Data, &m.comments[i])
+	}
+
+	media.Likes = &instagram.MediaLikes{Count: len(m.likes)}
+	for _, id := range m.likes {
+		media.Likes.Data = append(media.Likes.Data, shortUser(s.users[id]))
+		if id == viewer {
+			media.UserHasLiked = true
+		}
+	}
+
+	return media
+}
+
+// visibleMedia returns the media accepted by keep whose user is visible to
+// viewer, newest first.
+func (s *Server) visibleMedia(viewer string, keep func(m *instagram.Media) bool) []*mediaRecord {
+	var list []*mediaRecord
+	for i := len(s.media) - 1; i >= 0; i-- {
+		m := s.media[i]
+		if keep(&m.media) && s.canView(viewer, s.users[m.media.User.ID]) {
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+// count returns the page size requested by r.
+func (s *Server) count(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("count")
+	if v == "" {
+		if s.PageSize > 0 {
+			return s.PageSize, nil
+		}
+		return DefaultPageSize, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, invalidParameter("invalid count %q", v)
+	}
+	return n, nil
+}
+
+// mediaSeq returns the publication order of a media ID, which starts with the
+// sequence number of the media as IDs returned by Instagram do.
+func (s *Server) mediaSeq(id string) int {
+	if m, ok := s.mediaByID[id]; ok {
+		return m.seq
+	}
+	n, _ := strconv.Atoi(strings.SplitN(id, "_", 2)[0])
+	return n
+}
+
+// paginateMedia answers with a page of list, which is newest first, selected
+// by the min_id, max_id and count parameters of r, as seen by viewer.
+func (s *Server) paginateMedia(r *http.Request, viewer string, list []*mediaRecord) (*page, error) {
+	return s.paginate(r, viewer, list, false)
+}
+
+// paginateTagMedia is like paginateMedia, for tag media, which are selected
+// by min_tag_id and max_tag_id instead. Tag IDs are the sequence numbers of
+// the media.
+func (s *Server) paginateTagMedia(r *http.Request, viewer string, list []*mediaRecord) (*page, error) {
+	return s.paginate(r, viewer, list, true)
+}
+
+func (s *Server) paginate(r *http.Request, viewer string, list []*mediaRecord, byTag bool) (*page, error) {
+	count, err := s.count(r)
+	if err != nil {
+		return nil, err
+	}
+
+	q := r.URL.Query()
+	minSeq, maxSeq := -1, -1
+	if byTag {
+		if v := q.Get("min_tag_id"); v != "" {
+			minSeq, _ = strconv.Atoi(v)
+		}
+		if v := q.Get("max_tag_id"); v != "" {
+			maxSeq, _ = strconv.Atoi(v)
+		}
+	} else {
+		if v := q.Get("min_id"); v != "" {
+			minSeq = s.mediaSeq(v)
+		}
+		if v := q.Get("max_id"); v != "" {
+			maxSeq = s.mediaSeq(v)
+		}
+	}
+
+	var selected []*mediaRecord
+	for _, m := range list {
+		if minSeq >= 0 && m.seq <= minSeq {
+			continue
+		}
+		if maxSeq >= 0 && m.seq >= maxSeq {
+			continue
+		}
+		selected = append(selected, m)
+	}
+
+	p := &page{pagination: new(instagram.ResponsePagination)}
+	if len(selected) > count {
+		selected = selected[:count]
+		last := selected[count-1]
+		if byTag {
+			next := strconv.Itoa(last.seq)
+			p.pagination.NextMaxTagID = next
+			p.pagination.NextURL = nextURL(r, "max_tag_id", next)
+		} else {
+			p.pagination.NextMaxID = last.media.ID
+			p.pagination.NextURL = nextURL(r, "max_id", last.media.ID)
+		}
+	}
+
+	if byTag && len(selected) > 0 {
+		p.pagination.MinTagID = strconv.Itoa(selected[0].seq)
+	}
+
+	data := make([]instagram.Media, 0, len(selected))
+	for _, m := range selected {
+		data = append(data, s.renderMedia(m, viewer))
+	}
+	p.data = data
+	return p, nil
+}
+
+func nextURL(r *http.Request, key, value string) string {
+	u := *r.URL
+	u.Scheme, u.Host = "http", r.Host
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// paginateUsers answers with a page of the users with the given IDs, selected
+// by the cursor and count parameters of r.
+func (s *Server) paginateUsers(r *http.Request, ids []string) (*page, error) {
+	count, err := s.count(r)
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		if start, err = strconv.Atoi(c); err != nil || start < 0 {
+			return nil, invalidParameter("invalid cursor %q", c)
+		}
+	}
+	if start > len(ids) {
+		start = len(ids)
+	}
+
+	p := &page{pagination: new(instagram.ResponsePagination)}
+	end := start + count
+	if end < len(ids) {
+		next := strconv.Itoa(end)
+		p.pagination.Cursor = next
+		p.pagination.NextURL = nextURL(r, "cursor", next)
+	} else {
+		end = len(ids)
+	}
+
+	data := make([]instagram.User, 0, end-start)
+	for _, id := range ids[start:end] {
+		data = append(data, *shortUser(s.users[id]))
+	}
+	p.data = data
+	return p, nil
+}
+
+func (s *Server) getUser(viewer, id string) (*page, error) {
+	u, err := s.lookupUser(viewer, id)
+	if err != nil {
+		return nil, err
+	}
+	return &page{data: s.renderUser(u)}, nil
+}
+
+func (s *Server) searchUsers(r *http.Request) (*page, error) {
+	q := strings.ToLower(r.URL.Query().Get("q"))
+	if q == "" {
+		return nil, invalidParameter("missing q parameter")
+	}
+	count, err := s.count(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []instagram.User{}
+	for _, id := range s.userOrder {
+		u := s.users[id]
+		if len(data) < count && (strings.Contains(strings.ToLower(u.user.Username), q) || strings.Contains(strings.ToLower(u.user.FullName), q)) {
+			data = append(data, *shortUser(u))
+		}
+	}
+	return &page{data: data}, nil
+}
+
+func (s *Server) feed(r *http.Request, viewer string) (*page, error) {
+	self, err := s.self(viewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.paginateMedia(r, viewer, s.visibleMedia(viewer, func(m *instagram.Media) bool {
+		return m.User.ID == viewer || self.follows[m.User.ID]
+	}))
+}
+
+func (s *Server) userMedia(r *http.Request, viewer, id string) (*page, error) {
+	u, err := s.lookupUser(viewer, id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.canView(viewer, u) {
+		return nil, errNotAllowed
+	}
+	return s.paginateMedia(r, viewer, s.visibleMedia(viewer, func(m *instagram.Media) bool {
+		return m.User.ID == u.user.ID
+	}))
+}
+
+// liked answers with the media liked by the viewer, most recently liked
+// first, paginated by max_like_id.
+func (s *Server) liked(r *http.Request, viewer string) (*page, error) {
+	self, err := s.self(viewer)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.count(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*mediaRecord
+	for i := len(self.liked) - 1; i >= 0; i-- {
+		list = append(list, s.mediaByID[self.liked[i]])
+	}
+	if maxID := r.URL.Query().Get("max_like_id"); maxID != "" {
+		for i, m := range list {
+			if m.media.ID == maxID {
+				list = list[i+1:]
+				break
+			}
+		}
+	}
+
+	p := &page{pagination: new(instagram.ResponsePagination)}
+	if len(list) > count {
+		list = list[:count]
+		next := list[count-1].media.ID
+		p.pagination.NextMaxLikeID = next
+		p.pagination.NextURL = nextURL(r, "max_like_id", next)
+	}
+
+	data := make([]instagram.Media, 0, len(list))
+	for _, m := range list {
+		data = append(data, s.renderMedia(m, viewer))
+	}
+	p.data = data
+	return p, nil
+}
+
+func (s *Server) follows(r *http.Request, viewer, id string, followedBy bool) (*page, error) {
+	u, err := s.lookupUser(viewer, id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.canView(viewer, u) {
+		return nil, errNotAllowed
+	}
+
+	var ids []string
+	for _, other := range s.userOrder {
+		if (!followedBy && u.follows[other]) || (followedBy && s.users[other].follows[u.user.ID]) {
+			ids = append(ids, other)
+		}
+	}
+	return s.paginateUsers(r, ids)
+}
+
+func (s *Server) requestedBy(r *http.Request, viewer string) (*page, error) {
+	self, err := s.self(viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, other := range s.userOrder {
+		if self.requestedBy[other] {
+			ids = append(ids, other)
+		}
+	}
+	return s.paginateUsers(r, ids)
+}
+
+// relationship carries out action, if not empty, between the viewer and a
+// user and answers with their relationship.
+func (s *Server) relationship(viewer, id, action string) (*page, error) {
+	self, err := s.self(viewer)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.lookupUser(viewer, id)
+	if err != nil {
+		return nil, err
+	}
+	target := u.user.ID
+
+	switch action {
+	case "":
+	case "follow":
+		if u.blocked[viewer] {
+			return nil, errNotAllowed
+		}
+		if u.private {
+			u.requestedBy[viewer] = true
+		} else {
+			self.follows[target] = true
+		}
+	case "unfollow":
+		delete(self.follows, target)
+		delete(u.requestedBy, viewer)
+	case "approve":
+		if self.requestedBy[target] {
+			u.follows[viewer] = true
+			delete(self.requestedBy, target)
+		}
+	case "deny":
+		delete(self.requestedBy, target)
+	case "block":
+		self.blocked[target] = true
+		delete(u.follows, viewer)
+		delete(self.requestedBy, target)
+	case "unblock":
+		delete(self.blocked, target)
+	default:
+		return nil, invalidParameter("invalid action %q", action)
+	}
+
+	rel := &instagram.Relationship{
+		OutgoingStatus:      "none",
+		IncomingStatus:      "none",
+		TargetUserIsPrivate: u.private,
+	}
+	switch {
+	case self.follows[target]:
+		rel.OutgoingStatus = "follows"
+	case u.requestedBy[viewer]:
+		rel.OutgoingStatus = "requested"
+	}
+	switch {
+	case self.blocked[target]:
+		rel.IncomingStatus = "blocked_by_you"
+	case u.follows[viewer]:
+		rel.IncomingStatus = "followed_by"
+	case self.requestedBy[target]:
+		rel.IncomingStatus = "requested_by"
+	}
+	return &page{data: rel}, nil
+}
+
+// lookupMedia returns the media with the given ID or shortcode, if visible to
+// viewer.
+func (s *Server) lookupMedia(viewer, id, code string) (*mediaRecord, error) {
+	var m *mediaRecord
+	if code != "" {
+		for _, rec := range s.media {
+			if rec.shortcode == code {
+				m = rec
+			}
+		}
+	} else {
+		m = s.mediaByID[id]
+	}
+	if m == nil {
+		return nil, notFound("invalid media id")
+	}
+	if !s.canView(viewer, s.users[m.media.User.ID]) {
+		return nil, errNotAllowed
+	}
+	return m, nil
+}
+
+func (s *Server) getMedia(viewer, id, code string) (*page, error) {
+	m, err := s.lookupMedia(viewer, id, code)
+	if err != nil {
+		return nil, err
+	}
+	return &page{data: s.renderMedia(m, viewer)}, nil
+}
+
+func (s *Server) searchMedia(r *http.Request, viewer string) (*page, error) {
+	lat, lng, distance, err := area(r)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.count(r)
+	if err != nil {
+		return nil, err
+	}
+
+	q := r.URL.Query()
+	minTime, _ := strconv.ParseInt(q.Get("min_timestamp"), 10, 64)
+	maxTime, _ := strconv.ParseInt(q.Get("max_timestamp"), 10, 64)
+
+	list := s.visibleMedia(viewer, func(m *instagram.Media) bool {
+		if m.Location == nil || haversine(lat, lng, m.Location.Latitude, m.Location.Longitude) > distance {
+			return false
+		}
+		return (minTime == 0 || m.CreatedTime >= minTime) && (maxTime == 0 || m.CreatedTime <= maxTime)
+	})
+	if len(list) > count {
+		list = list[:count]
+	}
+
+	data := make([]instagram.Media, 0, len(list))
+	for _, m := range list {
+		data = append(data, s.renderMedia(m, viewer))
+	}
+	return &page{data: data}, nil
+}
+
+// popular answers with the media of the last day, most liked first.
+func (s *Server) popular(viewer string) (*page, error) {
+	since := time.Now().Add(-24 * time.Hour).Unix()
+	list := s.visibleMedia(viewer, func(m *instagram.Media) bool {
+		return m.CreatedTime >= since
+	})
+	sort.SliceStable(list, func(i, j int) bool {
+		return len(list[i].likes) > len(list[j].likes)
+	})
+
+	size := s.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	if len(list) > size {
+		list = list[:size]
+	}
+
+	data := make([]instagram.Media, 0, len(list))
+	for _, m := range list {
+		data = append(data, s.renderMedia(m, viewer))
+	}
+	return &page{data: data}, nil
+}
+
+func (s *Server) comments(viewer, mediaID string) (*page, error) {
+	m, err := s.lookupMedia(viewer, mediaID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &page{data: append([]instagram.Comment{}, m.comments...)}, nil
+}
+
+func (s *Server) addComment(viewer, mediaID, text string) (*page, error) {
+	self, err := s.self(viewer)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.lookupMedia(viewer, mediaID, "")
+	if err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return nil, invalidParameter("missing text parameter")
+	}
+
+	s.seq++
+	c := instagram.Comment{
+		ID:          strconv.Itoa(s.seq),
+		CreatedTime: time.Now().Unix(),
+		Text:        text,
+		From:        shortUser(self),
+	}
+	m.comments = append(m.comments, c)
+	return &page{data: c}, nil
+}
+
+func (s *Server) deleteComment(viewer, mediaID, commentID string) (*page, error) {
+	if _, err := s.self(viewer); err != nil {
+		return nil, err
+	}
+	m, err := s.lookupMedia(viewer, mediaID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, c := range m.comments {
+		if c.ID != commentID {
+			continue
+		}
+		if c.From.ID != viewer && m.media.User.ID != viewer {
+			return nil, errNotAllowed
+		}
+		m.comments = append(m.comments[:i], m.comments[i+1:]...)
+		return &page{}, nil
+	}
+	return nil, notFound("invalid comment id")
+}
+
+func (s *Server) likes(viewer, mediaID string) (*page, error) {
+	m, err := s.lookupMedia(viewer, mediaID, "")
+	if err != nil {
+		return nil, err
+	}
+	data := []instagram.User{}
+	for _, id := range m.likes {
+		data = append(data, *shortUser(s.users[id]))
+	}
+	return &page{data: data}, nil
+}
+
+func (s *Server) like(viewer, mediaID string, like bool) (*page, error) {
+	self, err := s.self(viewer)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.lookupMedia(viewer, mediaID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	m.likes = remove(m.likes, viewer)
+	self.liked = remove(self.liked, m.media.ID)
+	if like {
+		m.likes = append(m.likes, viewer)
+		self.liked = append(self.liked, m.media.ID)
+	}
+	return &page{}, nil
+}
+
+func (s *Server) getTag(name string) (*page, error) {
+	name = strings.ToLower(name)
+	n := 0
+	for _, m := range s.media {
+		if contains(m.media.Tags, name) {
+			n++
+		}
+	}
+	return &page{data: instagram.Tag{Name: name, MediaCount: n}}, nil
+}
+
+// searchTags answers with the tags starting with the q parameter, most used
+// first.
+func (s *Server) searchTags(r *http.Request) (*page, error) {
+	q := strings.ToLower(r.URL.Query().Get("q"))
+	if q == "" {
+		return nil, invalidParameter("missing q parameter")
+	}
+
+	counts := make(map[string]int)
+	for _, m := range s.media {
+		for _, tag := range m.media.Tags {
+			if strings.HasPrefix(tag, q) {
+				counts[tag]++
+			}
+		}
+	}
+
+	data := []instagram.Tag{}
+	for name, n := range counts {
+		data = append(data, instagram.Tag{Name: name, MediaCount: n})
+	}
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].MediaCount != data[j].MediaCount {
+			return data[i].MediaCount > data[j].MediaCount
+		}
+		return data[i].Name < data[j].Name
+	})
+	return &page{data: data}, nil
+}
+
+// searchLocations answers with the locations around the lat and lng
+// parameters, nearest first.
+func (s *Server) searchLocations(r *http.Request) (*page, error) {
+	lat, lng, distance, err := area(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []instagram.Location{}
+	for _, l := range s.locations {
+		if haversine(lat, lng, l.Latitude, l.Longitude) <= distance {
+			data = append(data, *l)
+		}
+	}
+	sort.Slice(data, func(i, j int) bool {
+		di := haversine(lat, lng, data[i].Latitude, data[i].Longitude)
+		dj := haversine(lat, lng, data[j].Latitude, data[j].Longitude)
+		if di != dj {
+			return di < dj
+		}
+		return data[i].ID < data[j].ID
+	})
+	return &page{data: data}, nil
+}
+
+// area returns the search area given by the lat, lng and distance parameters
+// of r. The distance defaults to 1km and is capped at 5km, as on Instagram.
+func area(r *http.Request) (lat, lng, distance float64, err error) {
+	q := r.URL.Query()
+	lat, err1 := strconv.ParseFloat(q.Get("lat"), 64)
+	lng, err2 := strconv.ParseFloat(q.Get("lng"), 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, 0, invalidParameter("missing or invalid lat and lng parameters")
+	}
+
+	distance = 1000
+	if v := q.Get("distance"); v != "" {
+		if distance, err = strconv.ParseFloat(v, 64); err != nil {
+			return 0, 0, 0, invalidParameter("invalid distance %q", v)
+		}
+	}
+	if distance > 5000 {
+		distance = 5000
+	}
+	return lat, lng, distance, nil
+}
+
+// haversine returns the distance in meters between two points.
+func haversine(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371000
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dlat, dlng := rad(lat2-lat1), rad(lng2-lng1)
+	a := math.Sin(dlat/2)*math.Sin(dlat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dlng/2)*math.Sin(dlng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(list []string, s string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
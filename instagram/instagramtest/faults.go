@@ -0,0 +1,287 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+// FaultKind is a kind of failure a Server can inject.
+type FaultKind int
+
+// Kinds of faults.
+const (
+	// FaultRateLimited answers 429 with X-Ratelimit-Remaining at 0 and an
+	// OAuthRateLimitException.
+	FaultRateLimited FaultKind = iota
+
+	// FaultOops answers 500 with the plain-text "Oops, an error occurred."
+	// body Instagram sometimes sends.
+	FaultOops
+
+	// FaultMetaError answers an error wrapped in the {"meta": ...} envelope.
+	FaultMetaError
+
+	// FaultBareError answers an error object without envelope, as Instagram
+	// does for some errors.
+	FaultBareError
+
+	// FaultHTML answers an HTML error page, as sent by a failing proxy.
+	FaultHTML
+
+	// FaultTruncated answers the normal status and headers, but only the
+	// first half of the body.
+	FaultTruncated
+
+	// FaultSlow waits for Delay before answering normally.
+	FaultSlow
+
+	// FaultReset resets the connection in the middle of the status line. As
+	// part of an answer has been received, net/http does not transparently
+	// retry the request on a new connection.
+	FaultReset
+)
+
+// Fault describes a failure injected by a Server in place of, or before, the
+// normal answer to a request. The constructors below build the usual ones.
+type Fault struct {
+	Kind FaultKind
+
+	// Status is the HTTP status of FaultMetaError, FaultBareError and
+	// FaultHTML answers. It defaults to 400 for errors and 502 for HTML.
+	Status int
+
+	// ErrorType and ErrorMessage describe the error of FaultMetaError and
+	// FaultBareError answers.
+	ErrorType    string
+	ErrorMessage string
+
+	// Delay is how long FaultSlow waits. With other kinds, it delays the
+	// fault. Waiting stops if the client goes away.
+	Delay time.Duration
+
+	// RetryAfter, if set, is sent as the Retry-After header of
+	// FaultRateLimited answers, in seconds.
+	RetryAfter time.Duration
+}
+
+// RateLimited returns a FaultRateLimited fault.
+func RateLimited() Fault { return Fault{Kind: FaultRateLimited} }
+
+// Oops returns a FaultOops fault.
+func Oops() Fault { return Fault{Kind: FaultOops} }
+
+// MetaError returns a FaultMetaError fault answering an error of the given
+// status and type.
+func MetaError(status int, errorType, message string) Fault {
+	return Fault{Kind: FaultMetaError, Status: status, ErrorType: errorType, ErrorMessage: message}
+}
+
+// BareError returns a FaultBareError fault answering an error of the given
+// status and type.
+func BareError(status int, errorType, message string) Fault {
+	return Fault{Kind: FaultBareError, Status: status, ErrorType: errorType, ErrorMessage: message}
+}
+
+// HTMLError returns a FaultHTML fault with the given status.
+func HTMLError(status int) Fault { return Fault{Kind: FaultHTML, Status: status} }
+
+// Truncated returns a FaultTruncated fault.
+func Truncated() Fault { return Fault{Kind: FaultTruncated} }
+
+// Slow returns a FaultSlow fault waiting for d.
+func Slow(d time.Duration) Fault { return Fault{Kind: FaultSlow, Delay: d} }
+
+// ConnectionReset returns a FaultReset fault.
+func ConnectionReset() Fault { return Fault{Kind: FaultReset} }
+
+// faultRule holds the faults injected for the requests matching a method and
+// path.
+type faultRule struct {
+	method string
+	path   []string
+	faults []Fault
+	every  bool
+}
+
+func (rule *faultRule) match(r *http.Request) bool {
+	if rule.method != "" && rule.method != r.Method {
+		return false
+	}
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/"), "/")
+	if len(path) != len(rule.path) {
+		return false
+	}
+	for i, p := range rule.path {
+		if p != "*" && p != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Inject scripts faults for the requests with the given method, or any method
+// if empty, and path. The path is relative to the API root, with "*" matching
+// any segment, e.g. "tags/*/media/recent" or "oauth/access_token". The next
+// matching requests get the faults, one each and in order; the following ones
+// are answered normally.
+//
+// Rules are tried in the order they were added; a rule whose faults have all
+// been injected no longer applies.
+func (s *Server) Inject(method, path string, faults ...Fault) {
+	s.addFaultRule(&faultRule{method: method, path: splitPattern(path), faults: faults})
+}
+
+// InjectEvery makes every request with the given method and path fail with f,
+// until ClearFaults is called. See Inject for the patterns.
+func (s *Server) InjectEvery(method, path string, f Fault) {
+	s.addFaultRule(&faultRule{method: method, path: splitPattern(path), faults: []Fault{f}, every: true})
+}
+
+// ClearFaults removes the faults not injected yet.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+func (s *Server) addFaultRule(rule *faultRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, rule)
+}
+
+func splitPattern(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// nextFault returns the fault to inject for r, if any.
+func (s *Server) nextFault(r *http.Request) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, rule := range s.faults {
+		if !rule.match(r) {
+			continue
+		}
+		f := rule.faults[0]
+		if !rule.every {
+			rule.faults = rule.faults[1:]
+			if len(rule.faults) == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f, true
+	}
+	return Fault{}, false
+}
+
+// inject applies f to the request r. It reports whether r has been answered;
+// if not, it must be served normally.
+func (s *Server) inject(w http.ResponseWriter, r *http.Request, f Fault) bool {
+	if f.Delay > 0 {
+		t := time.NewTimer(f.Delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	switch f.Kind {
+	case FaultSlow:
+		return false
+
+	case FaultRateLimited:
+		limit := s.RateLimit
+		if limit <= 0 {
+			limit = DefaultRateLimit
+		}
+		w.Header().Set("X-Ratelimit-Limit", strconv.Itoa(limit))
+		w.Header().Set("X-Ratelimit-Remaining", "0")
+		if f.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(f.RetryAfter/time.Second)))
+		}
+		writeError(w, errRateLimited, true)
+
+	case FaultOops:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Oops, an error occurred.")
+
+	case FaultMetaError, FaultBareError:
+		e := &apiError{status: f.Status, typ: f.ErrorType, message: f.ErrorMessage}
+		if e.status == 0 {
+			e.status = http.StatusBadRequest
+		}
+		writeError(w, e, f.Kind == FaultMetaError)
+
+	case FaultHTML:
+		status := f.Status
+		if status == 0 {
+			status = http.StatusBadGateway
+		}
+		text := http.StatusText(status)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "<html><head><title>%d %s</title></head><body><h1>%d %s</h1></body></html>\n", status, text, status, text)
+
+	case FaultTruncated:
+		rec := httptest.NewRecorder()
+		s.serve(rec, r)
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		body := rec.Body.Bytes()
+		w.Write(body[:len(body)/2])
+
+	case FaultReset:
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			panic("instagramtest: connection cannot be reset")
+		}
+		conn, buf, err := hj.Hijack()
+		if err != nil {
+			panic(err)
+		}
+		buf.WriteString("HTTP/1.1 2")
+		buf.Flush()
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			// Discard unsent data and send a RST rather than a FIN.
+			tcp.SetLinger(0)
+		}
+		conn.Close()
+
+	default:
+		panic(fmt.Sprintf("instagramtest: unknown fault kind %d", f.Kind))
+	}
+
+	return true
+}
+
+// writeError answers e, wrapped in the {"meta": ...} envelope if meta is set.
+func writeError(w http.ResponseWriter, e *apiError, meta bool) {
+	body := instagram.ResponseMeta{Code: e.status, ErrorType: e.typ, ErrorMessage: e.message}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(e.status)
+	if meta {
+		json.NewEncoder(w).Encode(map[string]interface{}{"meta": body})
+	} else {
+		json.NewEncoder(w).Encode(body)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagramtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+func TestServer_Inject(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	client := s.Client("alice-token")
+
+	tests := []struct {
+		fault Fault
+		check func(err error) bool
+	}{
+		{RateLimited(), func(err error) bool { return errors.Is(err, instagram.ErrRateLimited) }},
+		{Oops(), func(err error) bool {
+			var e *instagram.Error
+			return errors.As(err, &e) && e.ErrorMessage == "Oops, an error occurred." && errors.Is(err, instagram.ErrServer)
+		}},
+		{MetaError(http.StatusBadRequest, "APINotAllowedError", "private"), func(err error) bool {
+			return errors.Is(err, instagram.ErrPrivateUser)
+		}},
+		{BareError(http.StatusBadRequest, "OAuthAccessTokenException", "revoked"), func(err error) bool {
+			return errors.Is(err, instagram.ErrInvalidToken)
+		}},
+		{HTMLError(http.StatusBadGateway), func(err error) bool { return errors.Is(err, instagram.ErrServer) }},
+		{Truncated(), func(err error) bool { return err != nil }},
+		{ConnectionReset(), func(err error) bool { return err != nil }},
+	}
+
+	for _, tt := range tests {
+		s.Inject("GET", "users/*", tt.fault)
+		if _, err := client.Users.Get("2"); !tt.check(err) {
+			t.Errorf("Users.Get with fault %+v returned %v", tt.fault, err)
+		}
+		if _, err := client.Users.Get("2"); err != nil {
+			t.Errorf("Users.Get after fault %+v returned error: %v", tt.fault, err)
+		}
+	}
+}
+
+func TestServer_Inject_retry(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	client := s.Client("alice-token")
+	client.Retry = &instagram.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+
+	s.Inject("", "users/self", Oops(), RateLimited())
+	if _, err := client.Users.Get("self"); err != nil {
+		t.Errorf("Users.Get with retries returned error: %v", err)
+	}
+
+	s.InjectEvery("GET", "users/self", HTMLError(http.StatusServiceUnavailable))
+	if _, err := client.Users.Get("self"); !errors.Is(err, instagram.ErrServer) {
+		t.Errorf("Users.Get failing every attempt returned %v, want ErrServer", err)
+	}
+	s.ClearFaults()
+	if _, err := client.Users.Get("self"); err != nil {
+		t.Errorf("Users.Get after ClearFaults returned error: %v", err)
+	}
+}
+
+func TestServer_Inject_slow(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	client := s.Client("alice-token")
+	s.Inject("GET", "users/self", Slow(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := client.Users.GetContext(ctx, "self"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Users.Get with a slow answer returned %v, want context.DeadlineExceeded", err)
+	}
+}
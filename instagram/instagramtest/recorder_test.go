@@ -0,0 +1,157 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagramtest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+func recorderClient(rec *Recorder, baseURL string) *instagram.Client {
+	client := instagram.NewClient(&http.Client{Transport: rec})
+	client.BaseURL, _ = url.Parse(baseURL + "/v1/")
+	client.AccessToken = "alice-token"
+	client.ClientSecret = "shh"
+	return client
+}
+
+func TestRecorder(t *testing.T) {
+	s := newTestServer()
+	s.AddMedia(instagram.Media{User: &instagram.User{ID: "1"}, Tags: []string{"go"}})
+	baseURL := s.URL
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+
+	rec, err := NewRecorder(fixture, ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	rec.Transport = s.Server.Client().Transport
+
+	client := recorderClient(rec, baseURL)
+	recorded, _, err := client.Tags.RecentMedia("go", &instagram.Parameters{Count: 5})
+	if err != nil {
+		t.Fatalf("RecentMedia returned error: %v", err)
+	}
+	if _, err := client.Users.Get("self"); err != nil {
+		t.Fatalf("Users.Get returned error: %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	s.Close()
+
+	data, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"alice-token", "shh"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("fixture contains secret %q", secret)
+		}
+	}
+
+	rec, err = NewRecorder(fixture, ModeReplay)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	rec.Strict = true
+	client = recorderClient(rec, baseURL)
+
+	replayed, _, err := client.Tags.RecentMedia("go", &instagram.Parameters{Count: 5})
+	if err != nil {
+		t.Fatalf("replayed RecentMedia returned error: %v", err)
+	}
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed RecentMedia returned %+v, want %+v", replayed, recorded)
+	}
+	if u, err := client.Users.Get("self"); err != nil || u.ID != "1" {
+		t.Errorf("replayed Users.Get returned %+v, %v", u, err)
+	}
+
+	if _, err := client.Users.Get("self"); err == nil {
+		t.Errorf("strict replay of a used interaction returned no error")
+	}
+	if _, err := client.Users.Get("2"); err == nil {
+		t.Errorf("strict replay of an unknown request returned no error")
+	}
+
+	rec.Strict = false
+	if _, err := client.Users.Get("self"); err != nil {
+		t.Errorf("non-strict replay of a used interaction returned error: %v", err)
+	}
+}
+
+func TestRecorder_paginated(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	for i := 0; i < 3; i++ {
+		s.AddMedia(instagram.Media{User: &instagram.User{ID: "1"}, Tags: []string{"go"}})
+	}
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+
+	rec, err := NewRecorder(fixture, ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	rec.Transport = s.Server.Client().Transport
+	client := recorderClient(rec, s.URL)
+	client.SignedRequests = true
+
+	it := client.Tags.RecentMediaIterator("go", &instagram.Parameters{Count: 1})
+	n := 0
+	for it.Next(context.Background()) {
+		n++
+	}
+	if err := it.Err(); err != nil || n != 3 {
+		t.Fatalf("crawl returned %d media, %v", n, err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "next_url") {
+		t.Fatalf("fixture has no pagination:\n%s", data)
+	}
+	for _, secret := range []string{"alice-token", "shh"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("fixture contains secret %q:\n%s", secret, data)
+		}
+	}
+}
+
+func TestRedactResponseBody(t *testing.T) {
+	body := `{"pagination": {"next_url": "http://x/v1/tags/go/media/recent?access_token=s3cr3t\u0026count=1\u0026sig=abc\u0026max_tag_id=3"}, "access_token": "s3cr3t"}`
+	got := redactResponseBody([]byte(body))
+	if strings.Contains(got, "s3cr3t") || strings.Contains(got, "abc") {
+		t.Errorf("redactResponseBody returned %s", got)
+	}
+	if !strings.Contains(got, `count=1\u0026sig=REDACTED\u0026max_tag_id=3`) {
+		t.Errorf("redactResponseBody altered other parameters: %s", got)
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	a, _ := url.Parse("https://api.instagram.com/v1/tags/go/media/recent?count=5&access_token=x&min_id=1")
+	b, _ := url.Parse("http://127.0.0.1:1234/v1/tags/go/media/recent?min_id=1&count=5&access_token=y&sig=z")
+	if matchKey("GET", a) != matchKey("GET", b) {
+		t.Errorf("matchKey(%v) = %q, differs from matchKey(%v) = %q", a, matchKey("GET", a), b, matchKey("GET", b))
+	}
+	if matchKey("GET", a) == matchKey("POST", a) {
+		t.Errorf("matchKey ignores the method")
+	}
+}
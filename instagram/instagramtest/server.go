@@ -0,0 +1,452 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package instagramtest provides an in-memory fake of the Instagram API, for
+// testing code built on the instagram package without reaching Instagram.
+//
+// The fake keeps users, media, tags, locations, comments, likes,
+// relationships and realtime subscriptions, and answers like the v1 API: with
+// the meta/data/pagination envelope, Instagram's error types and the
+// X-Ratelimit headers.
+//
+//	srv := instagramtest.NewServer()
+//	defer srv.Close()
+//
+//	srv.AddUser(instagram.User{ID: "1", Username: "alice"}, "alice-token")
+//	srv.AddMedia(instagram.Media{User: &instagram.User{ID: "1"}, Tags: []string{"go"}})
+//
+//	client := srv.Client("alice-token")
+//	media, _, err := client.Tags.RecentMedia("go", nil)
+package instagramtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carbocation/go-instagram/instagram"
+	"github.com/carbocation/go-instagram/instagram/realtimetest"
+)
+
+// Defaults used by Server for zero fields.
+const (
+	DefaultRateLimit = 5000
+	DefaultPageSize  = 20
+)
+
+// Server is a fake Instagram API served over HTTP. The API lives under /v1/
+// and the OAuth token endpoint under /oauth/, as on api.instagram.com, so
+// that clients only need their BaseURL changed; Client returns such a client.
+//
+// The fields must be set before the first request. The seeding methods may be
+// called at any time. A Server is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// RateLimit is the number of calls allowed per access token, or client ID
+	// for unauthenticated calls, and hour. DefaultRateLimit is used when zero.
+	RateLimit int
+
+	// PageSize is the number of items per page when the count parameter is
+	// not given. DefaultPageSize is used when zero.
+	PageSize int
+
+	// Realtime serves the subscriptions endpoint and delivers notifications to
+	// the subscribers. Media added with AddMedia are notified to the
+	// subscriptions to their user, tags and location.
+	Realtime *realtimetest.Simulator
+
+	mu        sync.Mutex
+	users     map[string]*userRecord
+	userOrder []string
+	tokens    map[string]string
+	codes     map[string]string
+	media     []*mediaRecord
+	mediaByID map[string]*mediaRecord
+	locations map[string]*instagram.Location
+	quotas    map[string]*quota
+	faults    []*faultRule
+	seq       int
+}
+
+type userRecord struct {
+	user        instagram.User
+	private     bool
+	follows     map[string]bool
+	requestedBy map[string]bool
+	blocked     map[string]bool
+	liked       []string
+}
+
+type mediaRecord struct {
+	media     instagram.Media
+	seq       int
+	shortcode string
+	comments  []instagram.Comment
+	likes     []string
+}
+
+type quota struct {
+	reset time.Time
+	used  int
+}
+
+// NewServer starts and returns a new, empty Server. The caller should call
+// Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		Realtime:  realtimetest.NewSimulator(""),
+		users:     make(map[string]*userRecord),
+		tokens:    make(map[string]string),
+		codes:     make(map[string]string),
+		mediaByID: make(map[string]*mediaRecord),
+		locations: make(map[string]*instagram.Location),
+		quotas:    make(map[string]*quota),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Client returns a client of the API served by s, authenticated with
+// accessToken if it is not empty.
+func (s *Server) Client(accessToken string) *instagram.Client {
+	c := instagram.NewClient(s.Server.Client())
+	c.BaseURL, _ = url.Parse(s.URL + "/v1/")
+	c.AccessToken = accessToken
+	if accessToken == "" {
+		c.ClientID = "instagramtest"
+	}
+	return c
+}
+
+// AddUser adds u, replacing the user with the same ID if any. If accessToken
+// is not empty, requests carrying it are authenticated as u.
+func (s *Server) AddUser(u instagram.User, accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u.ID == "" {
+		panic("instagramtest: user without ID")
+	}
+	u.Counts = nil
+	if rec, ok := s.users[u.ID]; ok {
+		rec.user = u
+	} else {
+		s.users[u.ID] = &userRecord{
+			user:        u,
+			follows:     make(map[string]bool),
+			requestedBy: make(map[string]bool),
+			blocked:     make(map[string]bool),
+		}
+		s.userOrder = append(s.userOrder, u.ID)
+	}
+	if accessToken != "" {
+		s.tokens[accessToken] = u.ID
+	}
+}
+
+// SetPrivate makes the media and relationships of a user visible only to its
+// followers, and its follows into requests.
+func (s *Server) SetPrivate(userID string, private bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user(userID).private = private
+}
+
+// Follow makes a user follow another one, regardless of privacy.
+func (s *Server) Follow(userID, targetID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user(targetID)
+	s.user(userID).follows[targetID] = true
+}
+
+// AuthorizationCode returns a code that the OAuth token endpoint exchanges for
+// accessToken, as if its user had authorized the application.
+func (s *Server) AuthorizationCode(accessToken string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	code := "code-" + strconv.Itoa(s.seq)
+	s.codes[code] = accessToken
+	return code
+}
+
+// AddLocation adds l, replacing the location with the same ID if any. Location
+// IDs must be numeric, as they are in media.
+func (s *Server) AddLocation(l instagram.Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := strconv.Atoi(l.ID); err != nil {
+		panic("instagramtest: location ID must be numeric: " + l.ID)
+	}
+	s.locations[l.ID] = &l
+}
+
+// AddMedia publishes m, which needs the ID of a known user, and returns it as
+// stored. Media are ordered by publication: each one is newer than the
+// previous ones. The ID, link and creation time are set if empty, and tags
+// are lowercased. The realtime subscriptions to its user, tags and location
+// are notified in the background.
+func (s *Server) AddMedia(m instagram.Media) instagram.Media {
+	m, notifications := s.addMedia(m)
+	if len(s.Realtime.Subscriptions()) > 0 {
+		go s.Realtime.Notify(context.Background(), notifications...)
+	}
+	return m
+}
+
+// addMedia stores m and returns it with the realtime notifications it causes.
+func (s *Server) addMedia(m instagram.Media) (instagram.Media, []instagram.RealtimeResponse) {
+	if m.User == nil {
+		panic("instagramtest: media without user")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner := s.user(m.User.ID)
+	m.User = &instagram.User{
+		ID:             owner.user.ID,
+		Username:       owner.user.Username,
+		FullName:       owner.user.FullName,
+		ProfilePicture: owner.user.ProfilePicture,
+	}
+
+	s.seq++
+	rec := &mediaRecord{seq: s.seq, shortcode: "s" + strconv.FormatInt(int64(s.seq), 36)}
+	if m.ID == "" {
+		m.ID = fmt.Sprintf("%d_%s", s.seq, owner.user.ID)
+	}
+	if m.Type == "" {
+		m.Type = "image"
+	}
+	if m.Link == "" {
+		m.Link = "https://instagram.com/p/" + rec.shortcode + "/"
+	} else if code := shortcode(m.Link); code != "" {
+		rec.shortcode = code
+	}
+	if m.CreatedTime == 0 {
+		m.CreatedTime = time.Now().Unix()
+	}
+	if m.Location != nil {
+		if l, ok := s.locations[strconv.Itoa(m.Location.ID)]; ok && m.Location.Name == "" {
+			m.Location = &instagram.MediaLocation{ID: m.Location.ID, Name: l.Name, Latitude: l.Latitude, Longitude: l.Longitude}
+		}
+	}
+	if m.Tags != nil {
+		// The caller's slice is left alone.
+		tags := make([]string, len(m.Tags))
+		for i, tag := range m.Tags {
+			tags[i] = strings.ToLower(tag)
+		}
+		m.Tags = tags
+	}
+	m.Comments, m.Likes, m.UserHasLiked = nil, nil, false
+
+	rec.media = m
+	s.media = append(s.media, rec)
+	s.mediaByID[m.ID] = rec
+
+	var notifications []instagram.RealtimeResponse
+	notify := func(object, objectID string) {
+		notifications = append(notifications, instagram.RealtimeResponse{
+			Object:   object,
+			ObjectID: objectID,
+			Data:     &instagram.RealtimeData{MediaID: m.ID},
+		})
+	}
+	notify(instagram.ObjectUser, owner.user.ID)
+	for _, tag := range m.Tags {
+		notify(instagram.ObjectTag, tag)
+	}
+	if m.Location != nil && m.Location.ID != 0 {
+		notify(instagram.ObjectLocation, strconv.Itoa(m.Location.ID))
+	}
+	return m, notifications
+}
+
+// user returns the record of a user, which must exist. s.mu must be held.
+func (s *Server) user(id string) *userRecord {
+	rec, ok := s.users[id]
+	if !ok {
+		panic("instagramtest: unknown user " + id)
+	}
+	return rec
+}
+
+// shortcode returns the shortcode in a media link, such as
+// https://instagram.com/p/<shortcode>/.
+func shortcode(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) == 2 && parts[0] == "p" {
+		return parts[1]
+	}
+	return ""
+}
+
+// apiError is an error answered by the API.
+type apiError struct {
+	status  int
+	typ     string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.typ + ": " + e.message
+}
+
+var (
+	errMissingToken = &apiError{http.StatusBadRequest, "OAuthParameterException", "Missing client_id or access_token URL parameter."}
+	errSelfToken    = &apiError{http.StatusBadRequest, "OAuthParameterException", "Missing access_token URL parameter."}
+	errInvalidToken = &apiError{http.StatusBadRequest, "OAuthAccessTokenException", "The access_token provided is invalid."}
+	errRateLimited  = &apiError{http.StatusTooManyRequests, "OAuthRateLimitException", "The maximum number of requests per hour has been exceeded."}
+	errNotAllowed   = &apiError{http.StatusBadRequest, "APINotAllowedError", "you cannot view this resource"}
+	errNoEndpoint   = &apiError{http.StatusNotFound, "APINotFoundError", "this endpoint does not exist"}
+)
+
+func notFound(format string, args ...interface{}) *apiError {
+	return &apiError{http.StatusBadRequest, "APINotFoundError", fmt.Sprintf(format, args...)}
+}
+
+func invalidParameter(format string, args ...interface{}) *apiError {
+	return &apiError{http.StatusBadRequest, "APIInvalidParametersError", fmt.Sprintf(format, args...)}
+}
+
+// page is the data and pagination of a successful response.
+type page struct {
+	data       interface{}
+	pagination *instagram.ResponsePagination
+}
+
+// ServeHTTP serves the API, unless a fault is injected for r.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f, ok := s.nextFault(r); ok && s.inject(w, r, f) {
+		return
+	}
+	s.serve(w, r)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/oauth/access_token":
+		s.serveAccessToken(w, r)
+	case strings.HasPrefix(r.URL.Path, "/v1/subscriptions"):
+		s.Realtime.ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/v1/"):
+		s.serveAPI(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	viewer, key, err := s.authenticate(r)
+	if err == nil {
+		err = s.limit(w, key)
+	}
+
+	var p *page
+	if err == nil {
+		p, err = s.route(r, viewer, strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/"), "/"))
+	}
+
+	if err != nil {
+		e, ok := err.(*apiError)
+		if !ok {
+			e = &apiError{http.StatusInternalServerError, "APIError", err.Error()}
+		}
+		writeError(w, e, true)
+		return
+	}
+
+	body := map[string]interface{}{
+		"meta": instagram.ResponseMeta{Code: http.StatusOK},
+		"data": p.data,
+	}
+	if p.pagination != nil {
+		body["pagination"] = p.pagination
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(body)
+}
+
+// authenticate returns the ID of the user r is authenticated as, if any, and
+// the key its rate limit is tracked under.
+func (s *Server) authenticate(r *http.Request) (viewer, key string, err error) {
+	q := r.URL.Query()
+	if token := q.Get("access_token"); token != "" {
+		id, ok := s.tokens[token]
+		if !ok {
+			return "", "", errInvalidToken
+		}
+		return id, token, nil
+	}
+	if id := q.Get("client_id"); id != "" {
+		return "", id, nil
+	}
+	return "", "", errMissingToken
+}
+
+// limit counts a call against the quota of key and sets the rate limit
+// headers.
+func (s *Server) limit(w http.ResponseWriter, key string) error {
+	limit := s.RateLimit
+	if limit <= 0 {
+		limit = DefaultRateLimit
+	}
+
+	now := time.Now()
+	q := s.quotas[key]
+	if q == nil || now.After(q.reset) {
+		q = &quota{reset: now.Add(time.Hour)}
+		s.quotas[key] = q
+	}
+
+	w.Header().Set("X-Ratelimit-Limit", strconv.Itoa(limit))
+	if q.used >= limit {
+		w.Header().Set("X-Ratelimit-Remaining", "0")
+		return errRateLimited
+	}
+	q.used++
+	w.Header().Set("X-Ratelimit-Remaining", strconv.Itoa(limit-q.used))
+	return nil
+}
+
+// serveAccessToken exchanges authorization codes for access tokens. Like
+// Instagram, it answers errors with a bare error object.
+func (s *Server) serveAccessToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.codes[r.FormValue("code")]
+	if r.Method != "POST" || !ok {
+		writeError(w, &apiError{http.StatusBadRequest, "OAuthException", "No matching code found."}, false)
+		return
+	}
+	delete(s.codes, r.FormValue("code"))
+
+	rec, ok := s.users[s.tokens[token]]
+	if !ok {
+		writeError(w, &apiError{http.StatusBadRequest, "OAuthException", "The access_token provided is invalid."}, false)
+		return
+	}
+	u := rec.user
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(instagram.Token{AccessToken: token, User: &u})
+}
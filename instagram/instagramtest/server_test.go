@@ -0,0 +1,229 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagramtest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+func newTestServer() *Server {
+	s := NewServer()
+	s.AddUser(instagram.User{ID: "1", Username: "alice"}, "alice-token")
+	s.AddUser(instagram.User{ID: "2", Username: "bob"}, "bob-token")
+	s.AddUser(instagram.User{ID: "3", Username: "carol"}, "carol-token")
+	s.AddLocation(instagram.Location{ID: "10", Name: "Park", Latitude: 48.85, Longitude: 2.35})
+	return s
+}
+
+func mediaIDs(media []instagram.Media) []string {
+	var ids []string
+	for _, m := range media {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func TestServer_media(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	var want []string
+	tags := []string{"Go"}
+	for i := 0; i < 5; i++ {
+		m := s.AddMedia(instagram.Media{
+			User:     &instagram.User{ID: "2"},
+			Tags:     tags,
+			Location: &instagram.MediaLocation{ID: 10},
+		})
+		want = append([]string{m.ID}, want...)
+	}
+	if tags[0] != "Go" {
+		t.Errorf("AddMedia changed the tags of the caller to %v", tags)
+	}
+
+	client := s.Client("alice-token")
+	ctx := context.Background()
+
+	var got []string
+	it := client.Tags.RecentMediaIterator("go", &instagram.Parameters{Count: 2})
+	for it.Next(ctx) {
+		got = append(got, it.Media().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("RecentMediaIterator returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tag media are %v, want %v", got, want)
+	}
+
+	media, _, err := client.Locations.RecentMediaContext(ctx, "10", &instagram.Parameters{MinID: want[2]})
+	if err != nil {
+		t.Fatalf("Locations.RecentMedia returned error: %v", err)
+	}
+	if got := mediaIDs(media); !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("location media newer than %s are %v, want %v", want[2], got, want[:2])
+	}
+
+	if err := client.Likes.Like(want[0]); err != nil {
+		t.Fatalf("Like returned error: %v", err)
+	}
+	if err := client.Comments.Add(want[0], []string{"nice"}); err != nil {
+		t.Fatalf("Comments.Add returned error: %v", err)
+	}
+	m, err := client.Media.Get(want[0])
+	if err != nil {
+		t.Fatalf("Media.Get returned error: %v", err)
+	}
+	if m.Likes.Count != 1 || !m.UserHasLiked || m.Comments.Count != 1 || m.Comments.Data[0].Text != "nice" {
+		t.Errorf("Media.Get returned likes %+v and comments %+v", m.Likes, m.Comments)
+	}
+	if m.Location == nil || m.Location.Name != "Park" {
+		t.Errorf("Media.Get returned location %+v, want Park", m.Location)
+	}
+
+	liked, _, err := client.Users.LikedMedia(nil)
+	if err != nil {
+		t.Fatalf("LikedMedia returned error: %v", err)
+	}
+	if got := mediaIDs(liked); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("LikedMedia returned %v, want %v", got, want[:1])
+	}
+
+	u, err := client.Users.Get("2")
+	if err != nil {
+		t.Fatalf("Users.Get returned error: %v", err)
+	}
+	if u.Username != "bob" || u.Counts.Media != 5 {
+		t.Errorf("Users.Get returned %+v with counts %+v", u, u.Counts)
+	}
+
+	if _, err := client.Media.Get("404_1"); !errors.Is(err, instagram.ErrNotFound) {
+		t.Errorf("Media.Get of an unknown media returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestServer_AddMedia_unknownUser(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("AddMedia of an unknown user did not panic")
+			}
+		}()
+		s.AddMedia(instagram.Media{User: &instagram.User{ID: "9"}})
+	}()
+
+	// The server must still be usable after the panic.
+	m := s.AddMedia(instagram.Media{User: &instagram.User{ID: "1"}})
+	if _, err := s.Client("alice-token").Media.Get(m.ID); err != nil {
+		t.Errorf("Media.Get after a panic in AddMedia returned error: %v", err)
+	}
+}
+
+func TestServer_relationships(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	s.SetPrivate("2", true)
+	s.AddMedia(instagram.Media{User: &instagram.User{ID: "2"}})
+
+	alice, bob := s.Client("alice-token"), s.Client("bob-token")
+
+	if _, _, err := alice.Users.RecentMedia("2", nil); !errors.Is(err, instagram.ErrPrivateUser) {
+		t.Errorf("RecentMedia of a private user returned %v, want ErrPrivateUser", err)
+	}
+
+	rel, err := alice.Relationships.Follow("2")
+	if err != nil {
+		t.Fatalf("Follow returned error: %v", err)
+	}
+	if rel.OutgoingStatus != "requested" || !rel.TargetUserIsPrivate {
+		t.Errorf("Follow of a private user returned %+v", rel)
+	}
+
+	requests, _, err := bob.Relationships.RequestedBy()
+	if err != nil {
+		t.Fatalf("RequestedBy returned error: %v", err)
+	}
+	if len(requests) != 1 || requests[0].ID != "1" {
+		t.Errorf("RequestedBy returned %+v, want alice", requests)
+	}
+
+	if _, err := bob.Relationships.Approve("1"); err != nil {
+		t.Fatalf("Approve returned error: %v", err)
+	}
+	media, _, err := alice.Users.RecentMedia("2", nil)
+	if err != nil {
+		t.Fatalf("RecentMedia of a followed private user returned error: %v", err)
+	}
+	if len(media) != 1 {
+		t.Errorf("RecentMedia returned %d media, want 1", len(media))
+	}
+
+	s.Follow("3", "2")
+	followers, _, err := alice.Relationships.FollowedBy("2", &instagram.Parameters{Count: 1})
+	if err != nil {
+		t.Fatalf("FollowedBy returned error: %v", err)
+	}
+	if len(followers) != 1 || followers[0].ID != "1" {
+		t.Errorf("FollowedBy returned %+v, want alice", followers)
+	}
+}
+
+func TestServer_errors(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	s.RateLimit = 2
+
+	client := s.Client("unknown-token")
+	if _, err := client.Users.Get("1"); !errors.Is(err, instagram.ErrInvalidToken) {
+		t.Errorf("Users.Get with an unknown token returned %v, want ErrInvalidToken", err)
+	}
+
+	client = s.Client("carol-token")
+	for i := 0; i < 2; i++ {
+		_, resp, err := client.Users.GetContext(context.Background(), "self")
+		if err != nil {
+			t.Fatalf("Users.Get returned error: %v", err)
+		}
+		if rl, _ := resp.GetRatelimit(); rl.Limit != 2 || rl.Remaining != 1-i {
+			t.Errorf("Users.Get returned rate limit %+v", rl)
+		}
+	}
+	if _, err := client.Users.Get("self"); !errors.Is(err, instagram.ErrRateLimited) {
+		t.Errorf("Users.Get past the rate limit returned %v, want ErrRateLimited", err)
+	}
+}
+
+func TestServer_oauth(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	client := s.Client("")
+	token, err := client.Exchange(s.AuthorizationCode("bob-token"), "http://example.com/cb")
+	if err != nil {
+		t.Fatalf("Exchange returned error: %v", err)
+	}
+	if token.AccessToken != "bob-token" || token.User.ID != "2" {
+		t.Errorf("Exchange returned %+v", token)
+	}
+
+	if _, err := client.Exchange("bogus", "http://example.com/cb"); err == nil {
+		t.Errorf("Exchange of an unknown code returned no error")
+	}
+
+	_, err = client.Exchange(s.AuthorizationCode("nobody-token"), "http://example.com/cb")
+	if e, ok := err.(*instagram.Error); !ok || e.StatusCode != 400 || e.ErrorType != "OAuthException" {
+		t.Errorf("Exchange of a code for an unknown token returned %v", err)
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// MediaIterator walks every media of a paginated endpoint, fetching pages as
+// needed. Create one with the services' *Iterator methods and use it as:
+//
+//	it := client.Users.RecentMediaIterator("3", nil)
+//	for it.Next(ctx) {
+//		m := it.Media()
+//		// ...
+//	}
+//	if err := it.Err(); err != nil {
+//		// ...
+//	}
+//
+// The limits must be set before the first call to Next.
+type MediaIterator struct {
+	// MaxPages stops the iteration once that many pages have been fetched.
+	// Zero means no limit.
+	MaxPages int
+
+	// MaxItems stops the iteration once that many media have been returned.
+	// Zero means no limit.
+	MaxItems int
+
+	// Stop, if set, ends the iteration at the first media for which it returns
+	// true. That media is not returned. See OlderThan.
+	Stop func(m *Media) bool
+
+	get   func(ctx context.Context, opt *Parameters) ([]Media, *Response, error)
+	p     pager
+	page  []Media
+	media *Media
+}
+
+// Next advances to the next media, fetching the next page when the current one
+// is exhausted. It returns false when there are no more media, a limit has
+// been reached or an error occurred.
+func (it *MediaIterator) Next(ctx context.Context) bool {
+	if it.p.done || (it.MaxItems > 0 && it.p.items >= it.MaxItems) {
+		return false
+	}
+
+	for len(it.page) == 0 {
+		ok := it.p.fetch(ctx, it.MaxPages, func(ctx context.Context, opt *Parameters) (*Response, error) {
+			media, resp, err := it.get(ctx, opt)
+			it.page = media
+			return resp, err
+		})
+		if !ok {
+			return false
+		}
+	}
+
+	it.media, it.page = &it.page[0], it.page[1:]
+	if it.Stop != nil && it.Stop(it.media) {
+		it.media = nil
+		it.p.done = true
+		return false
+	}
+	it.p.items++
+
+	return true
+}
+
+// Media returns the current media.
+func (it *MediaIterator) Media() *Media {
+	return it.media
+}
+
+// Err returns the error, if any, that ended the iteration.
+func (it *MediaIterator) Err() error {
+	return it.p.err
+}
+
+// Response returns the response of the last fetched page.
+func (it *MediaIterator) Response() *Response {
+	return it.p.resp
+}
+
+// UserIterator walks every user of a paginated endpoint. It is used like
+// MediaIterator.
+type UserIterator struct {
+	// MaxPages stops the iteration once that many pages have been fetched.
+	// Zero means no limit.
+	MaxPages int
+
+	// MaxItems stops the iteration once that many users have been returned.
+	// Zero means no limit.
+	MaxItems int
+
+	// Stop, if set, ends the iteration at the first user for which it returns
+	// true. That user is not returned.
+	Stop func(u *User) bool
+
+	get  func(ctx context.Context, opt *Parameters) ([]User, *Response, error)
+	p    pager
+	page []User
+	user *User
+}
+
+// Next advances to the next user, fetching the next page when the current one
+// is exhausted. It returns false when there are no more users, a limit has
+// been reached or an error occurred.
+func (it *UserIterator) Next(ctx context.Context) bool {
+	if it.p.done || (it.MaxItems > 0 && it.p.items >= it.MaxItems) {
+		return false
+	}
+
+	for len(it.page) == 0 {
+		ok := it.p.fetch(ctx, it.MaxPages, func(ctx context.Context, opt *Parameters) (*Response, error) {
+			users, resp, err := it.get(ctx, opt)
+			it.page = users
+			return resp, err
+		})
+		if !ok {
+			return false
+		}
+	}
+
+	it.user, it.page = &it.page[0], it.page[1:]
+	if it.Stop != nil && it.Stop(it.user) {
+		it.user = nil
+		it.p.done = true
+		return false
+	}
+	it.p.items++
+
+	return true
+}
+
+// User returns the current user.
+func (it *UserIterator) User() *User {
+	return it.user
+}
+
+// Err returns the error, if any, that ended the iteration.
+func (it *UserIterator) Err() error {
+	return it.p.err
+}
+
+// Response returns the response of the last fetched page.
+func (it *UserIterator) Response() *Response {
+	return it.p.resp
+}
+
+// OlderThan returns a MediaIterator.Stop function ending the iteration at the
+// first media created before t. As endpoints list media newest first, this
+// yields the media created since t.
+func OlderThan(t time.Time) func(m *Media) bool {
+	return func(m *Media) bool {
+		return m.CreatedTime < t.Unix()
+	}
+}
+
+// pager holds the state shared by the iterators: the parameters of the next
+// request and the way they are derived from a page's pagination, which varies
+// between endpoints.
+type pager struct {
+	opt     Parameters
+	advance func(opt *Parameters, p *ResponsePagination) bool
+
+	pages int
+	items int
+	last  bool
+	done  bool
+	resp  *Response
+	err   error
+}
+
+func newPager(opt *Parameters, advance func(opt *Parameters, p *ResponsePagination) bool) pager {
+	p := pager{advance: advance}
+	if opt != nil {
+		p.opt = *opt
+	}
+	return p
+}
+
+// fetch requests the next page with get. It returns false, marking the pager
+// done, when there is no next page, maxPages has been reached or get fails.
+func (p *pager) fetch(ctx context.Context, maxPages int, get func(ctx context.Context, opt *Parameters) (*Response, error)) bool {
+	if p.done || p.last || (maxPages > 0 && p.pages >= maxPages) {
+		p.done = true
+		return false
+	}
+
+	opt := p.opt
+	resp, err := get(ctx, &opt)
+	p.pages++
+	if err != nil {
+		p.err = err
+		p.done = true
+		return false
+	}
+
+	p.resp = resp
+	if resp.Pagination == nil || !p.advance(&p.opt, resp.Pagination) {
+		p.last = true
+	}
+
+	return true
+}
+
+// advanceMaxID moves to the next page of endpoints paginated by max_id.
+func advanceMaxID(opt *Parameters, p *ResponsePagination) bool {
+	id := p.NextMaxID
+	if id == "" {
+		id = nextURLParam(p.NextURL, "max_id")
+	}
+	if id == "" || id == opt.MaxID {
+		return false
+	}
+	opt.MaxID = id
+	return true
+}
+
+// advanceMaxTagID moves to the next page of tag media, which are paginated by
+// max_tag_id.
+func advanceMaxTagID(opt *Parameters, p *ResponsePagination) bool {
+	id := p.NextMaxTagID
+	if id == "" {
+		id = nextURLParam(p.NextURL, "max_tag_id")
+	}
+	if id == "" || id == opt.MaxTagID {
+		return false
+	}
+	opt.MaxTagID = id
+	return true
+}
+
+// advanceMaxLikeID moves to the next page of endpoints paginated by
+// max_like_id, which are passed MaxID as max_like_id.
+func advanceMaxLikeID(opt *Parameters, p *ResponsePagination) bool {
+	id := p.NextMaxLikeID
+	if id == "" {
+		id = nextURLParam(p.NextURL, "max_like_id")
+	}
+	if id == "" || id == opt.MaxID {
+		return false
+	}
+	opt.MaxID = id
+	return true
+}
+
+// advanceCursor moves to the next page of endpoints paginated by cursor.
+func advanceCursor(opt *Parameters, p *ResponsePagination) bool {
+	cursor := p.Cursor
+	if cursor == "" {
+		cursor = nextURLParam(p.NextURL, "cursor")
+	}
+	if cursor == "" || cursor == opt.Cursor {
+		return false
+	}
+	opt.Cursor = cursor
+	return true
+}
+
+// nextURLParam returns the first non-empty of keys in the query of nextURL.
+func nextURLParam(nextURL string, keys ...string) string {
+	if nextURL == "" {
+		return ""
+	}
+	u, err := url.Parse(nextURL)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	for _, k := range keys {
+		if v := q.Get(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// NewMediaIterator returns an iterator calling get for each page of media,
+// starting with opt and following the max_id pagination. It is meant for
+// implementations of the service interfaces other than the services, such as
+// mocks.
+func NewMediaIterator(opt *Parameters, get func(ctx context.Context, opt *Parameters) ([]Media, *Response, error)) *MediaIterator {
+	return &MediaIterator{get: get, p: newPager(opt, advanceMaxID)}
+}
+
+// NewUserIterator returns an iterator calling get for each page of users,
+// starting with opt and following the cursor pagination. See NewMediaIterator.
+func NewUserIterator(opt *Parameters, get func(ctx context.Context, opt *Parameters) ([]User, *Response, error)) *UserIterator {
+	return &UserIterator{get: get, p: newPager(opt, advanceCursor)}
+}
+
+// RecentMediaIterator returns an iterator over the media published by a user.
+func (s *UsersService) RecentMediaIterator(userID string, opt *Parameters) *MediaIterator {
+	return &MediaIterator{
+		get: func(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
+			return s.RecentMediaContext(ctx, userID, opt)
+		},
+		p: newPager(opt, advanceMaxID),
+	}
+}
+
+// MediaFeedIterator returns an iterator over the authenticated user's feed.
+func (s *UsersService) MediaFeedIterator(opt *Parameters) *MediaIterator {
+	return &MediaIterator{
+		get: func(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
+			return s.MediaFeedContext(ctx, opt)
+		},
+		p: newPager(opt, advanceMaxID),
+	}
+}
+
+// LikedMediaIterator returns an iterator over the media liked by the
+// authenticated user.
+func (s *UsersService) LikedMediaIterator(opt *Parameters) *MediaIterator {
+	return &MediaIterator{
+		get: func(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
+			return s.LikedMediaContext(ctx, opt)
+		},
+		p: newPager(opt, advanceMaxLikeID),
+	}
+}
+
+// RecentMediaIterator returns an iterator over the media recently tagged with
+// tagName.
+func (s *TagsService) RecentMediaIterator(tagName string, opt *Parameters) *MediaIterator {
+	return &MediaIterator{
+		get: func(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
+			return s.RecentMediaContext(ctx, tagName, opt)
+		},
+		p: newPager(opt, advanceMaxTagID),
+	}
+}
+
+// RecentMediaIterator returns an iterator over the recent media of a location.
+func (s *LocationsService) RecentMediaIterator(locationID string, opt *Parameters) *MediaIterator {
+	return &MediaIterator{
+		get: func(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
+			return s.RecentMediaContext(ctx, locationID, opt)
+		},
+		p: newPager(opt, advanceMaxID),
+	}
+}
+
+// RecentMediaIterator returns an iterator over the recent media of a geography.
+func (s *GeographiesService) RecentMediaIterator(geoID string, opt *Parameters) *MediaIterator {
+	return &MediaIterator{
+		get: func(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
+			return s.RecentMediaContext(ctx, geoID, opt)
+		},
+		p: newPager(opt, advanceMaxID),
+	}
+}
+
+// FollowsIterator returns an iterator over the users a user follows.
+func (s *RelationshipsService) FollowsIterator(userID string, opt *Parameters) *UserIterator {
+	return &UserIterator{
+		get: func(ctx context.Context, opt *Parameters) ([]User, *Response, error) {
+			return s.FollowsContext(ctx, userID, opt)
+		},
+		p: newPager(opt, advanceCursor),
+	}
+}
+
+// FollowedByIterator returns an iterator over the users following a user.
+func (s *RelationshipsService) FollowedByIterator(userID string, opt *Parameters) *UserIterator {
+	return &UserIterator{
+		get: func(ctx context.Context, opt *Parameters) ([]User, *Response, error) {
+			return s.FollowedByContext(ctx, userID, opt)
+		},
+		p: newPager(opt, advanceCursor),
+	}
+}
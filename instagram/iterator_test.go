@@ -0,0 +1,188 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func mediaIDs(t *testing.T, it *MediaIterator) []string {
+	var ids []string
+	for it.Next(context.Background()) {
+		ids = append(ids, it.Media().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("MediaIterator returned error: %v", err)
+	}
+	return ids
+}
+
+func TestUsersService_RecentMediaIterator(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/1/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		testFormValues(t, r, values{"count": "2"})
+		switch r.FormValue("max_id") {
+		case "":
+			fmt.Fprint(w, `{"data": [{"id":"5"}, {"id":"4"}], "pagination": {"next_max_id": "4"}}`)
+		case "4":
+			fmt.Fprint(w, `{"data": [{"id":"3"}, {"id":"2"}], "pagination": {"next_max_id": "2"}}`)
+		case "2":
+			fmt.Fprint(w, `{"data": [{"id":"1"}], "pagination": {}}`)
+		default:
+			t.Errorf("unexpected max_id %q", r.FormValue("max_id"))
+		}
+	})
+
+	ids := mediaIDs(t, client.Users.RecentMediaIterator("1", &Parameters{Count: 2}))
+
+	want := []string{"5", "4", "3", "2", "1"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("RecentMediaIterator returned %v, want %v", ids, want)
+	}
+}
+
+func TestUsersService_LikedMediaIterator_nextURL(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/self/media/liked", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("max_like_id") {
+		case "":
+			fmt.Fprint(w, `{"data": [{"id":"2"}], "pagination": {"next_url": "https://api.instagram.com/v1/users/self/media/liked?max_like_id=9"}}`)
+		case "9":
+			fmt.Fprint(w, `{"data": [{"id":"1"}]}`)
+		default:
+			t.Errorf("unexpected max_like_id %q", r.FormValue("max_like_id"))
+		}
+	})
+
+	ids := mediaIDs(t, client.Users.LikedMediaIterator(nil))
+
+	want := []string{"2", "1"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("LikedMediaIterator returned %v, want %v", ids, want)
+	}
+}
+
+func TestTagsService_RecentMediaIterator(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// Like the real endpoint, only max_tag_id selects pages; max_id is
+	// ignored.
+	mux.HandleFunc("/tags/t/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("max_tag_id") {
+		case "":
+			fmt.Fprint(w, `{"data": [{"id":"5_1"}, {"id":"4_1"}], "pagination": {"next_max_tag_id": "104", "next_max_id": "4_1"}}`)
+		case "104":
+			fmt.Fprint(w, `{"data": [{"id":"3_1"}, {"id":"2_1"}], "pagination": {"next_url": "https://api.instagram.com/v1/tags/t/media/recent?max_tag_id=102"}}`)
+		case "102":
+			fmt.Fprint(w, `{"data": [{"id":"1_1"}], "pagination": {}}`)
+		default:
+			t.Errorf("unexpected max_tag_id %q", r.FormValue("max_tag_id"))
+		}
+	})
+
+	ids := mediaIDs(t, client.Tags.RecentMediaIterator("t", nil))
+
+	want := []string{"5_1", "4_1", "3_1", "2_1", "1_1"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("RecentMediaIterator returned %v, want %v", ids, want)
+	}
+}
+
+func TestMediaIterator_limits(t *testing.T) {
+	setup()
+	defer teardown()
+
+	pages := 0
+	mux.HandleFunc("/tags/t/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		fmt.Fprintf(w, `{"data": [{"id":"%d", "created_time": "%d"}, {"id":"%d", "created_time": "%d"}], "pagination": {"next_max_tag_id": "%d"}}`,
+			pages*2, 100-pages*2, pages*2+1, 100-pages*2-1, pages)
+	})
+
+	it := client.Tags.RecentMediaIterator("t", nil)
+	it.MaxPages = 2
+	if ids := mediaIDs(t, it); len(ids) != 4 || pages != 2 {
+		t.Errorf("MediaIterator with MaxPages = 2 returned %v after %d pages", ids, pages)
+	}
+
+	pages = 0
+	it = client.Tags.RecentMediaIterator("t", nil)
+	it.MaxItems = 3
+	if ids := mediaIDs(t, it); !reflect.DeepEqual(ids, []string{"2", "3", "4"}) {
+		t.Errorf("MediaIterator with MaxItems = 3 returned %v", ids)
+	}
+
+	pages = 0
+	it = client.Tags.RecentMediaIterator("t", nil)
+	it.Stop = OlderThan(time.Unix(95, 0))
+	if ids := mediaIDs(t, it); !reflect.DeepEqual(ids, []string{"2", "3", "4", "5"}) {
+		t.Errorf("MediaIterator with Stop = OlderThan(95) returned %v", ids)
+	}
+}
+
+func TestMediaIterator_error(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/locations/1/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("max_id") == "" {
+			fmt.Fprint(w, `{"data": [{"id":"2"}], "pagination": {"next_max_id": "2"}}`)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"meta": {"code": 400, "error_type": "APIError", "error_message": "boom"}}`)
+	})
+
+	it := client.Locations.RecentMediaIterator("1", nil)
+	n := 0
+	for it.Next(context.Background()) {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("MediaIterator returned %d media before the error, want 1", n)
+	}
+	if it.Err() == nil {
+		t.Errorf("MediaIterator.Err returned nil, want an error")
+	}
+}
+
+func TestRelationshipsService_FollowsIterator(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/1/follows", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("cursor") {
+		case "":
+			fmt.Fprint(w, `{"data": [{"id":"1"}], "pagination": {"next_cursor": "c"}}`)
+		case "c":
+			fmt.Fprint(w, `{"data": [{"id":"2"}], "pagination": {}}`)
+		}
+	})
+
+	it := client.Relationships.FollowsIterator("1", nil)
+	var ids []string
+	for it.Next(context.Background()) {
+		ids = append(ids, it.User().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("UserIterator returned error: %v", err)
+	}
+
+	want := []string{"1", "2"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("FollowsIterator returned %v, want %v", ids, want)
+	}
+}
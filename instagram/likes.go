@@ -6,6 +6,7 @@
 package instagram
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -21,38 +22,57 @@ type LikesService struct {
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/likes/#get_media_likes
 func (s *LikesService) MediaLikes(mediaID string) ([]User, error) {
+	users, _, err := s.MediaLikesContext(context.Background(), mediaID)
+	return users, err
+}
+
+// MediaLikesContext is like MediaLikes but stops when ctx is done and returns
+// the Response.
+func (s *LikesService) MediaLikesContext(ctx context.Context, mediaID string) ([]User, *Response, error) {
 	u := fmt.Sprintf("media/%v/likes", mediaID)
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	users := new([]User)
-	_, err = s.client.Do(req, users)
-	return *users, err
+	resp, err := s.client.DoContext(ctx, req, users)
+	return *users, resp, err
 }
 
 // Like a media.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/likes/#post_likes
 func (s *LikesService) Like(mediaID string) error {
-	return mediaLikesAction(s, mediaID, "POST")
+	_, err := s.LikeContext(context.Background(), mediaID)
+	return err
+}
+
+// LikeContext is like Like but stops when ctx is done and returns the Response.
+func (s *LikesService) LikeContext(ctx context.Context, mediaID string) (*Response, error) {
+	return mediaLikesAction(ctx, s, mediaID, "POST")
 }
 
 // Unlike a media.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/likes/#delete_likes
 func (s *LikesService) Unlike(mediaID string) error {
-	return mediaLikesAction(s, mediaID, "DELETE")
+	_, err := s.UnlikeContext(context.Background(), mediaID)
+	return err
+}
+
+// UnlikeContext is like Unlike but stops when ctx is done and returns the
+// Response.
+func (s *LikesService) UnlikeContext(ctx context.Context, mediaID string) (*Response, error) {
+	return mediaLikesAction(ctx, s, mediaID, "DELETE")
 }
 
-func mediaLikesAction(s *LikesService, mediaID, method string) error {
+func mediaLikesAction(ctx context.Context, s *LikesService, mediaID, method string) (*Response, error) {
 	u := fmt.Sprintf("media/%v/likes", mediaID)
-	req, err := s.client.NewRequest(method, u, "")
+	req, err := s.client.NewRequestWithContext(ctx, method, u, "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = s.client.Do(req, nil)
-	return err
+	return s.client.DoContext(ctx, req, nil)
 }
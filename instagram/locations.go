@@ -6,6 +6,7 @@
 package instagram
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -31,21 +32,37 @@ type Location struct {
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/locations/#get_locations
 func (s *LocationsService) Get(locationID string) (*Location, error) {
+	location, _, err := s.GetContext(context.Background(), locationID)
+	return location, err
+}
+
+// GetContext is like Get but stops when ctx is done and returns the Response.
+func (s *LocationsService) GetContext(ctx context.Context, locationID string) (*Location, *Response, error) {
 	u := fmt.Sprintf("locations/%v", locationID)
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	location := new(Location)
-	_, err = s.client.Do(req, location)
-	return location, err
+	resp, err := s.client.DoContext(ctx, req, location)
+	return location, resp, err
 }
 
 // RecentMedia gets a list of recent media from a given location.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/locations/#get_locations_media_recent
 func (s *LocationsService) RecentMedia(locationID string, opt *Parameters) ([]Media, *ResponsePagination, error) {
+	media, resp, err := s.RecentMediaContext(context.Background(), locationID, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, resp.Pagination, nil
+}
+
+// RecentMediaContext is like RecentMedia but stops when ctx is done and returns
+// the Response.
+func (s *LocationsService) RecentMediaContext(ctx context.Context, locationID string, opt *Parameters) ([]Media, *Response, error) {
 	u := fmt.Sprintf("locations/%v/media/recent", locationID)
 	if opt != nil {
 		params := url.Values{}
@@ -63,30 +80,32 @@ func (s *LocationsService) RecentMedia(locationID string, opt *Parameters) ([]Me
 		}
 		u += "?" + params.Encode()
 	}
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	media := new([]Media)
 
-	_, err = s.client.Do(req, media)
+	resp, err := s.client.DoContext(ctx, req, media)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
+		return nil, resp, err
 	}
 
-	return *media, page, err
+	return *media, resp, err
 }
 
 // Search for a location by geographic coordinate.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/locations/#get_locations_search
 func (s *LocationsService) Search(lat, lng float64, opt *Parameters) ([]Location, error) {
+	locations, _, err := s.SearchContext(context.Background(), lat, lng, opt)
+	return locations, err
+}
+
+// SearchContext is like Search but stops when ctx is done and returns the
+// Response.
+func (s *LocationsService) SearchContext(ctx context.Context, lat, lng float64, opt *Parameters) ([]Location, *Response, error) {
 	u := "locations/search"
 	params := url.Values{}
 	params.Add("lat", strconv.FormatFloat(lat, 'f', 7, 64))
@@ -101,12 +120,12 @@ func (s *LocationsService) Search(lat, lng float64, opt *Parameters) ([]Location
 		}
 	}
 	u += "?" + params.Encode()
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	locations := new([]Location)
-	_, err = s.client.Do(req, locations)
-	return *locations, err
+	resp, err := s.client.DoContext(ctx, req, locations)
+	return *locations, resp, err
 }
@@ -6,6 +6,7 @@
 package instagram
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -113,36 +114,59 @@ type MediaLocation struct {
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/media/#get_media
 func (s *MediaService) Get(mediaID string) (*Media, error) {
+	media, _, err := s.GetContext(context.Background(), mediaID)
+	return media, err
+}
+
+// GetContext is like Get but stops when ctx is done and returns the Response.
+func (s *MediaService) GetContext(ctx context.Context, mediaID string) (*Media, *Response, error) {
 	u := fmt.Sprintf("media/%v", mediaID)
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	media := new(Media)
-	_, err = s.client.Do(req, media)
-	return media, err
+	resp, err := s.client.DoContext(ctx, req, media)
+	return media, resp, err
 }
 
 // Get information about a media object with the shortcode.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/media/shortcode/#get_media
 func (s *MediaService) GetShortcode(shortcode string) (*Media, error) {
+	media, _, err := s.GetShortcodeContext(context.Background(), shortcode)
+	return media, err
+}
+
+// GetShortcodeContext is like GetShortcode but stops when ctx is done and
+// returns the Response.
+func (s *MediaService) GetShortcodeContext(ctx context.Context, shortcode string) (*Media, *Response, error) {
 	u := fmt.Sprintf("media/shortcode/%v", shortcode)
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	media := new(Media)
-	_, err = s.client.Do(req, media)
-	return media, err
+	resp, err := s.client.DoContext(ctx, req, media)
+	return media, resp, err
 }
 
 // Search return search results for media in a given area.
 //
 // http://instagram.com/developer/endpoints/media/#get_media_search
 func (s *MediaService) Search(opt *Parameters) ([]Media, *ResponsePagination, error) {
+	media, resp, err := s.SearchContext(context.Background(), opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, resp.Pagination, nil
+}
+
+// SearchContext is like Search but stops when ctx is done and returns the
+// Response.
+func (s *MediaService) SearchContext(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
 	u := "media/search"
 	if opt != nil {
 		params := url.Values{}
@@ -169,47 +193,47 @@ func (s *MediaService) Search(opt *Parameters) ([]Media, *ResponsePagination, er
 		u += "?" + params.Encode()
 	}
 
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	media := new([]Media)
 
-	_, err = s.client.Do(req, media)
+	resp, err := s.client.DoContext(ctx, req, media)
 	if err != nil {
-		return nil, nil, err
+		return nil, resp, err
 	}
 
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
-	}
-
-	return *media, page, err
+	return *media, resp, err
 }
 
 // Popular gets a list of what media is most popular at the moment.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/media/#get_media_popular
 func (s *MediaService) Popular() ([]Media, *ResponsePagination, error) {
-	u := "media/popular"
-	req, err := s.client.NewRequest("GET", u, "")
+	media, resp, err := s.PopularContext(context.Background())
 	if err != nil {
 		return nil, nil, err
 	}
+	return media, resp.Pagination, nil
+}
 
-	media := new([]Media)
-
-	_, err = s.client.Do(req, media)
+// PopularContext is like Popular but stops when ctx is done and returns the
+// Response.
+func (s *MediaService) PopularContext(ctx context.Context) ([]Media, *Response, error) {
+	u := "media/popular"
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
+	media := new([]Media)
+
+	resp, err := s.client.DoContext(ctx, req, media)
+	if err != nil {
+		return nil, resp, err
 	}
 
-	return *media, page, err
+	return *media, resp, err
 }
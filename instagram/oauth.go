@@ -0,0 +1,359 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Login permissions (scopes) that can be requested during authorization.
+//
+// Instagram API docs: http://instagram.com/developer/authentication/#scope
+const (
+	ScopeBasic         = "basic"
+	ScopePublicContent = "public_content"
+	ScopeFollowerList  = "follower_list"
+	ScopeComments      = "comments"
+	ScopeRelationships = "relationships"
+	ScopeLikes         = "likes"
+)
+
+const (
+	// authorizePath is the authorization endpoint, relative to BaseURL.
+	authorizePath = "../oauth/authorize/"
+
+	// accessTokenPath is the token endpoint, relative to BaseURL.
+	accessTokenPath = "../oauth/access_token"
+)
+
+// Token represents the result of a successful authorization code exchange.
+type Token struct {
+	AccessToken string `json:"access_token,omitempty"`
+	User        *User  `json:"user,omitempty"`
+}
+
+// AuthCodeURL returns the URL of Instagram's authorization page, to which the
+// user should be redirected to grant access to the application. Instagram
+// redirects back to redirectURI with a code (to be passed to Exchange) and the
+// given state, which should be verified by the caller to prevent CSRF.
+//
+// Instagram API docs: http://instagram.com/developer/authentication/#server_side
+func (c *Client) AuthCodeURL(redirectURI string, scopes []string, state string) string {
+	rel, _ := url.Parse(authorizePath)
+	u := c.BaseURL.ResolveReference(rel)
+
+	params := url.Values{}
+	params.Set("client_id", c.ClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("response_type", "code")
+	if len(scopes) > 0 {
+		params.Set("scope", strings.Join(scopes, " "))
+	}
+	if state != "" {
+		params.Set("state", state)
+	}
+	u.RawQuery = params.Encode()
+
+	return u.String()
+}
+
+// Exchange trades an authorization code received on redirectURI for an access
+// token. redirectURI must be the same one that was passed to AuthCodeURL. The
+// returned Token also carries the authenticated User.
+//
+// Exchange does not modify the client; set AccessToken from the returned Token
+// to make authenticated calls.
+//
+// Instagram API docs: http://instagram.com/developer/authentication/#server_side
+func (c *Client) Exchange(code, redirectURI string) (*Token, error) {
+	return c.ExchangeContext(context.Background(), code, redirectURI)
+}
+
+// ExchangeContext is like Exchange but carries ctx through the request.
+func (c *Client) ExchangeContext(ctx context.Context, code, redirectURI string) (*Token, error) {
+	rel, _ := url.Parse(accessTokenPath)
+	u := c.BaseURL.ResolveReference(rel)
+
+	params := url.Values{
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {redirectURI},
+		"code":          {code},
+	}
+
+	// The token endpoint lives outside the versioned API and answers with a
+	// bare object rather than the usual envelope, so NewRequest and Do are
+	// not used here.
+	req, err := http.NewRequestWithContext(ctx, "POST", u.String(), strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("User-Agent", c.UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	token := new(Token)
+	if err := json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// ErrInvalidState is returned when the state parameter of an authorization
+// callback is missing, malformed, was not signed by the handler, has expired
+// or does not match the state cookie of the browser.
+var ErrInvalidState = errors.New("instagram: invalid or expired OAuth state")
+
+// StateCookieName is the name of the cookie binding the OAuth state to the
+// browser which started the login.
+const StateCookieName = "instagram_oauth_state"
+
+// DefaultStateMaxAge is how long a state generated by OAuthHandler stays valid
+// when StateMaxAge is not set.
+const DefaultStateMaxAge = 10 * time.Minute
+
+// OAuthError represents an authorization failure reported by Instagram on the
+// redirect URI, e.g. when the user denies the request.
+//
+// Instagram API docs: http://instagram.com/developer/authentication/#server_side
+type OAuthError struct {
+	// Value of the error parameter, e.g. "access_denied".
+	Type string
+
+	// Value of the error_reason parameter, e.g. "user_denied".
+	Reason string
+
+	// Value of the error_description parameter.
+	Description string
+}
+
+func (err *OAuthError) Error() string {
+	return fmt.Sprintf("%s (%s): %s", err.Type, err.Reason, err.Description)
+}
+
+// OAuthHandler handles the redirect back from Instagram's authorization page.
+// It checks the state parameter, exchanges the code for an access token and
+// hands the result to Success. It can be plugged directly into any standard
+// http server.
+//
+// State values are a random nonce and a timestamp signed with StateKey. The
+// nonce is also stored in an HttpOnly cookie by AuthCodeURL and LoginHandler,
+// and callbacks whose state does not match the cookie of the browser are
+// rejected, so that a state obtained by an attacker cannot be used to log a
+// victim into the attacker's account.
+type OAuthHandler struct {
+	// Client used to exchange the code. Its ClientID and ClientSecret must be set.
+	Client *Client
+
+	// RedirectURI registered for the application; this handler must be served on it.
+	RedirectURI string
+
+	// Scopes requested by AuthCodeURL.
+	Scopes []string
+
+	// StateKey signs state values. The client's ClientSecret is used when empty.
+	StateKey []byte
+
+	// StateMaxAge limits how old a state may be. DefaultStateMaxAge is used when zero.
+	StateMaxAge time.Duration
+
+	// Success is called with the obtained token. It is responsible for writing
+	// the response, typically by storing the token and redirecting. When nil,
+	// a plain 200 response is written and the token is dropped.
+	Success func(w http.ResponseWriter, r *http.Request, token *Token)
+
+	// Failure is called with an *OAuthError, ErrInvalidState or the error
+	// returned by Exchange. When nil, a plain 400 or 502 response is written.
+	Failure func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// NewState returns a fresh signed state value.
+func (h *OAuthHandler) NewState() (string, error) {
+	if len(h.stateKey()) == 0 {
+		return "", errors.New("instagram: OAuthHandler needs a StateKey or a Client.ClientSecret")
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return h.signState(base64.RawURLEncoding.EncodeToString(nonce), time.Now()), nil
+}
+
+// VerifyState checks that state was produced by NewState and has not expired.
+func (h *OAuthHandler) VerifyState(state string) error {
+	parts := strings.Split(state, ".")
+	if len(parts) != 3 || len(h.stateKey()) == 0 {
+		return ErrInvalidState
+	}
+
+	ts, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ErrInvalidState
+	}
+
+	issued := time.Unix(ts, 0)
+	if !hmac.Equal([]byte(state), []byte(h.signState(parts[0], issued))) {
+		return ErrInvalidState
+	}
+
+	if age := time.Since(issued); age < -time.Minute || age > h.stateMaxAge() {
+		return ErrInvalidState
+	}
+
+	return nil
+}
+
+func (h *OAuthHandler) stateMaxAge() time.Duration {
+	if h.StateMaxAge == 0 {
+		return DefaultStateMaxAge
+	}
+	return h.StateMaxAge
+}
+
+func (h *OAuthHandler) stateKey() []byte {
+	if len(h.StateKey) != 0 {
+		return h.StateKey
+	}
+	return []byte(h.Client.ClientSecret)
+}
+
+// signState returns nonce and issue time joined with their signature.
+func (h *OAuthHandler) signState(nonce string, issued time.Time) string {
+	payload := nonce + "." + strconv.FormatInt(issued.Unix(), 10)
+	mac := hmac.New(sha256.New, h.stateKey())
+	mac.Write([]byte(payload))
+
+	return payload + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// AuthCodeURL sets the state cookie on w and returns the authorization URL
+// for the handler's redirect URI and scopes, carrying a fresh signed state.
+// The user should be redirected to it, as LoginHandler does.
+func (h *OAuthHandler) AuthCodeURL(w http.ResponseWriter) (string, error) {
+	state, err := h.NewState()
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     StateCookieName,
+		Value:    stateNonce(state),
+		Path:     "/",
+		MaxAge:   int(h.stateMaxAge() / time.Second),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.RedirectURI, "https:"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return h.Client.AuthCodeURL(h.RedirectURI, h.Scopes, state), nil
+}
+
+// LoginHandler returns a handler redirecting the user to Instagram's
+// authorization page, after setting the state cookie.
+func (h *OAuthHandler) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, err := h.AuthCodeURL(w)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, u, http.StatusFound)
+	})
+}
+
+// ServeHTTP handles the authorization callback. The state is verified first,
+// so that only callbacks of logins started by the browser, including denied
+// ones, reach Success or Failure with anything but ErrInvalidState.
+func (h *OAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	state := q.Get("state")
+	cookie, err := r.Cookie(StateCookieName)
+	if err == nil {
+		// The state is single-use.
+		http.SetCookie(w, &http.Cookie{Name: StateCookieName, Path: "/", MaxAge: -1})
+	}
+	if err != nil || !hmac.Equal([]byte(cookie.Value), []byte(stateNonce(state))) {
+		h.fail(w, r, ErrInvalidState, http.StatusBadRequest)
+		return
+	}
+	if err := h.VerifyState(state); err != nil {
+		h.fail(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if q.Get("error") != "" {
+		h.fail(w, r, &OAuthError{
+			Type:        q.Get("error"),
+			Reason:      q.Get("error_reason"),
+			Description: q.Get("error_description"),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	code := q.Get("code")
+	if code == "" {
+		h.fail(w, r, &OAuthError{Type: "invalid_request", Description: "missing code parameter"}, http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.Client.ExchangeContext(r.Context(), code, h.RedirectURI)
+	if err != nil {
+		h.fail(w, r, err, http.StatusBadGateway)
+		return
+	}
+
+	if h.Success == nil {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "Authorization succeeded.")
+		return
+	}
+	h.Success(w, r, token)
+}
+
+// stateNonce returns the nonce of a state, or "" if it is malformed.
+func stateNonce(state string) string {
+	parts := strings.Split(state, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[0]
+}
+
+func (h *OAuthHandler) fail(w http.ResponseWriter, r *http.Request, err error, status int) {
+	if h.Failure != nil {
+		h.Failure(w, r, err)
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
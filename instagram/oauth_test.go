@@ -0,0 +1,258 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_AuthCodeURL(t *testing.T) {
+	c := NewClient(nil)
+	c.ClientID = "cid"
+
+	got := c.AuthCodeURL("http://example.com/cb", []string{ScopeBasic, ScopeLikes}, "xyz")
+	u, err := url.Parse(got)
+	if err != nil {
+		t.Fatalf("AuthCodeURL returned invalid URL %q: %v", got, err)
+	}
+
+	if want := "https://api.instagram.com/oauth/authorize/"; u.Scheme+"://"+u.Host+u.Path != want {
+		t.Errorf("AuthCodeURL endpoint = %v, want %v", u.Scheme+"://"+u.Host+u.Path, want)
+	}
+
+	want := url.Values{
+		"client_id":     {"cid"},
+		"redirect_uri":  {"http://example.com/cb"},
+		"response_type": {"code"},
+		"scope":         {"basic likes"},
+		"state":         {"xyz"},
+	}
+	if !reflect.DeepEqual(u.Query(), want) {
+		t.Errorf("AuthCodeURL query = %+v, want %+v", u.Query(), want)
+	}
+}
+
+func TestClient_Exchange(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.ClientID = "cid"
+	client.ClientSecret = "secret"
+
+	mux.HandleFunc("/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testFormValues(t, r, values{
+			"client_id":     "cid",
+			"client_secret": "secret",
+			"grant_type":    "authorization_code",
+			"redirect_uri":  "http://example.com/cb",
+			"code":          "c0de",
+		})
+		fmt.Fprint(w, `{"access_token": "tok", "user": {"id": "1", "username": "u"}}`)
+	})
+
+	token, err := client.Exchange("c0de", "http://example.com/cb")
+	if err != nil {
+		t.Errorf("Exchange returned error: %v", err)
+	}
+
+	want := &Token{AccessToken: "tok", User: &User{ID: "1", Username: "u"}}
+	if !reflect.DeepEqual(token, want) {
+		t.Errorf("Exchange returned %+v, want %+v", token, want)
+	}
+}
+
+func TestClient_Exchange_error(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code": 400, "error_type": "OAuthException", "error_message": "No matching code found."}`)
+	})
+
+	_, err := client.Exchange("bad", "http://example.com/cb")
+	if err == nil {
+		t.Fatal("Exchange returned no error, want one")
+	}
+
+	want := &Error{Code: 400, ErrorType: "OAuthException", ErrorMessage: "No matching code found.", StatusCode: 400}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("Exchange returned error %+v, want %+v", err, want)
+	}
+}
+
+func newTestOAuthHandler(success func(w http.ResponseWriter, r *http.Request, token *Token), failed *error) *OAuthHandler {
+	return &OAuthHandler{
+		Client:      client,
+		RedirectURI: "http://example.com/cb",
+		Scopes:      []string{ScopeBasic},
+		Success:     success,
+		Failure: func(w http.ResponseWriter, r *http.Request, err error) {
+			*failed = err
+		},
+	}
+}
+
+// testLogin starts a login with h, returning the state of the authorization
+// URL and the state cookie.
+func testLogin(t *testing.T, h *OAuthHandler) (string, *http.Cookie) {
+	w := httptest.NewRecorder()
+	h.LoginHandler().ServeHTTP(w, httptest.NewRequest("GET", "/login", nil))
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("LoginHandler status = %v, want %v", w.Code, http.StatusFound)
+	}
+	u, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("LoginHandler redirected to invalid URL: %v", err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != StateCookieName || !cookies[0].HttpOnly {
+		t.Fatalf("LoginHandler set cookies %+v, want an HttpOnly %v", cookies, StateCookieName)
+	}
+	return u.Query().Get("state"), cookies[0]
+}
+
+func TestOAuthHandler_ServeHTTP(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.ClientSecret = "secret"
+
+	mux.HandleFunc("/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		testFormValues(t, r, values{"code": "c0de", "redirect_uri": "http://example.com/cb"})
+		fmt.Fprint(w, `{"access_token": "tok", "user": {"id": "1"}}`)
+	})
+
+	var got *Token
+	var failed error
+	h := newTestOAuthHandler(func(w http.ResponseWriter, r *http.Request, token *Token) {
+		got = token
+	}, &failed)
+
+	state, cookie := testLogin(t, h)
+
+	r := httptest.NewRequest("GET", "/cb?code=c0de&state="+url.QueryEscape(state), nil)
+	r.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if failed != nil {
+		t.Errorf("OAuthHandler failed with %v", failed)
+	}
+	want := &Token{AccessToken: "tok", User: &User{ID: "1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OAuthHandler passed %+v, want %+v", got, want)
+	}
+	if c := w.Result().Cookies(); len(c) != 1 || c[0].Name != StateCookieName || c[0].MaxAge >= 0 {
+		t.Errorf("OAuthHandler set cookies %+v, want the state cookie cleared", c)
+	}
+}
+
+func TestOAuthHandler_ServeHTTP_defaultSuccess(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.ClientSecret = "secret"
+
+	mux.HandleFunc("/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"access_token": "tok", "user": {"id": "1"}}`)
+	})
+
+	var failed error
+	h := newTestOAuthHandler(nil, &failed)
+	state, cookie := testLogin(t, h)
+
+	r := httptest.NewRequest("GET", "/cb?code=c0de&state="+url.QueryEscape(state), nil)
+	r.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if failed != nil {
+		t.Errorf("OAuthHandler failed with %v", failed)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("OAuthHandler status = %v, want %v", w.Code, http.StatusOK)
+	}
+}
+
+func TestOAuthHandler_ServeHTTP_denied(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.ClientSecret = "secret"
+
+	var failed error
+	h := newTestOAuthHandler(nil, &failed)
+	state, cookie := testLogin(t, h)
+
+	denied := "/cb?error=access_denied&error_reason=user_denied&error_description=The+user+denied+your+request"
+	r := httptest.NewRequest("GET", denied+"&state="+url.QueryEscape(state), nil)
+	r.AddCookie(cookie)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := &OAuthError{Type: "access_denied", Reason: "user_denied", Description: "The user denied your request"}
+	if !reflect.DeepEqual(failed, want) {
+		t.Errorf("OAuthHandler failed with %+v, want %+v", failed, want)
+	}
+
+	// A forged denial without the state of a login.
+	failed = nil
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", denied, nil))
+	if failed != ErrInvalidState {
+		t.Errorf("OAuthHandler with a forged denial failed with %v, want %v", failed, ErrInvalidState)
+	}
+}
+
+func TestOAuthHandler_ServeHTTP_invalidState(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.ClientSecret = "secret"
+
+	var failed error
+	h := newTestOAuthHandler(nil, &failed)
+
+	state, cookie := testLogin(t, h)
+	expired := h.signState(cookie.Value, time.Now().Add(-time.Hour))
+	other, _ := testLogin(t, h)
+	for _, s := range []string{"", "garbage", state + "0", expired, other} {
+		failed = nil
+		r := httptest.NewRequest("GET", "/cb?code=c0de&state="+url.QueryEscape(s), nil)
+		r.AddCookie(cookie)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+
+		if failed != ErrInvalidState {
+			t.Errorf("OAuthHandler with state %q failed with %v, want %v", s, failed, ErrInvalidState)
+		}
+	}
+
+	// A valid state from another browser, without the cookie.
+	failed = nil
+	r := httptest.NewRequest("GET", "/cb?code=c0de&state="+url.QueryEscape(state), nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if failed != ErrInvalidState {
+		t.Errorf("OAuthHandler without state cookie failed with %v, want %v", failed, ErrInvalidState)
+	}
+}
+
+func TestOAuthHandler_ServeHTTP_defaultFailure(t *testing.T) {
+	h := &OAuthHandler{Client: NewClient(nil)}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/cb?code=c0de", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("OAuthHandler status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultRatelimitWindow is the period over which Instagram applies its limits.
+//
+// Instagram API docs: http://instagram.com/developer/limits/
+const DefaultRatelimitWindow = time.Hour
+
+// RateLimiter paces requests according to the X-Ratelimit-Limit and
+// X-Ratelimit-Remaining headers returned by Instagram, so that a client
+// spreads its remaining calls over what is left of the window instead of
+// running into 429 responses. Quotas are tracked separately for every access
+// token, or client ID for unauthenticated calls.
+//
+// A RateLimiter is enabled by setting it on Client.RateLimiter. It may be
+// shared by several clients and is safe for concurrent use.
+type RateLimiter struct {
+	// Window is the period over which the limit applies. DefaultRatelimitWindow
+	// is used when zero.
+	Window time.Duration
+
+	mu     sync.Mutex
+	quotas map[string]*quota
+}
+
+// quota is the known state of the limit of a single key.
+type quota struct {
+	Ratelimit
+
+	reset    time.Time     // end of the current window
+	interval time.Duration // spacing between requests
+	next     time.Time     // earliest time for the next request
+}
+
+// NewRateLimiter returns a RateLimiter using DefaultRatelimitWindow.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{Window: DefaultRatelimitWindow}
+}
+
+func (l *RateLimiter) window() time.Duration {
+	if l.Window <= 0 {
+		return DefaultRatelimitWindow
+	}
+	return l.Window
+}
+
+// Wait blocks until a request may be sent for key, or until ctx is done, in
+// which case ctx's error is returned. Keys without known quota never wait.
+func (l *RateLimiter) Wait(ctx context.Context, key string) error {
+	l.mu.Lock()
+	q := l.quotas[key]
+	if q == nil {
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	if !q.reset.IsZero() && now.After(q.reset) {
+		// The window is over; the next response tells the new quota.
+		delete(l.quotas, key)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+
+	// Reserve a slot so that concurrent callers are spaced out as well.
+	slot := q.next
+	if slot.Before(now) {
+		slot = now
+	}
+	q.next = slot.Add(q.interval)
+	l.mu.Unlock()
+
+	return sleep(ctx, slot.Sub(now))
+}
+
+// Update records the limit reported by a response for key.
+func (l *RateLimiter) Update(key string, rl Ratelimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.quotas == nil {
+		l.quotas = make(map[string]*quota)
+	}
+
+	now := time.Now()
+	q := l.quotas[key]
+	if q == nil || now.After(q.reset) || rl.Remaining > q.Remaining {
+		// First sighting or replenished quota: a new window starts.
+		q = &quota{reset: now.Add(l.window())}
+		l.quotas[key] = q
+	}
+	q.Ratelimit = rl
+
+	if rl.Remaining <= 0 {
+		q.interval = 0
+		q.next = q.reset
+		return
+	}
+
+	q.interval = q.reset.Sub(now) / time.Duration(rl.Remaining)
+	if next := now.Add(q.interval); q.next.Before(next) {
+		q.next = next
+	}
+}
+
+// Remaining returns the last known limit for key, which is an access token or,
+// for unauthenticated calls, a client ID. It reports false if none is known.
+func (l *RateLimiter) Remaining(key string) (Ratelimit, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	q, ok := l.quotas[key]
+	if !ok {
+		return Ratelimit{}, false
+	}
+	return q.Ratelimit, true
+}
+
+// ratelimitKey returns the key the quota of req is tracked under: its access
+// token, or its client ID when it has none.
+func ratelimitKey(req *http.Request) string {
+	q := req.URL.Query()
+	if token := q.Get("access_token"); token != "" {
+		return token
+	}
+	return q.Get("client_id")
+}
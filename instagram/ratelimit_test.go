@@ -0,0 +1,78 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_spreadsRequests(t *testing.T) {
+	l := &RateLimiter{Window: 300 * time.Millisecond}
+	l.Update("k", Ratelimit{Limit: 10, Remaining: 3})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background(), "k"); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+
+	// Three calls over ~300ms leave ~100ms between them.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("3 Waits took %v, want at least 150ms", elapsed)
+	}
+}
+
+func TestRateLimiter_unknownKey(t *testing.T) {
+	l := NewRateLimiter()
+
+	start := time.Now()
+	if err := l.Wait(context.Background(), "k"); err != nil {
+		t.Errorf("Wait returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait for unknown key took %v", elapsed)
+	}
+}
+
+func TestRateLimiter_exhausted(t *testing.T) {
+	l := NewRateLimiter()
+	l.Update("k", Ratelimit{Limit: 10, Remaining: 0})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx, "k"); err != context.DeadlineExceeded {
+		t.Errorf("Wait returned %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClient_RateLimiter(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.AccessToken = "tok"
+	client.RateLimiter = NewRateLimiter()
+
+	mux.HandleFunc("/users/self", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Limit", "5000")
+		w.Header().Set("X-Ratelimit-Remaining", "4321")
+		fmt.Fprint(w, `{"data":{"id": "1"}}`)
+	})
+
+	if _, err := client.Users.Get(""); err != nil {
+		t.Fatalf("Users.Get returned error: %v", err)
+	}
+
+	rl, ok := client.RateLimiter.Remaining("tok")
+	if want := (Ratelimit{Limit: 5000, Remaining: 4321}); !ok || rl != want {
+		t.Errorf("RateLimiter.Remaining returned %+v, %v, want %+v, true", rl, ok, want)
+	}
+}
@@ -1,7 +1,13 @@
 package instagram
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -20,194 +26,446 @@ type Realtime struct {
 	ObjectID    string `json:"object_id,omitempty"`
 	Aspect      string `json:"aspect,omitempty"`
 	CallbackURL string `json:"callback_url,omitempty"`
+
+	// Lat, Lng and Radius are the area of geography subscriptions, when
+	// reported.
+	Lat    float64 `json:"lat,omitempty"`
+	Lng    float64 `json:"lng,omitempty"`
+	Radius int     `json:"radius,omitempty"`
 }
 
 // RealtimeResponse represents JSON structure
 type RealtimeResponse struct {
-	SubscriptionID int64  `json:"subscription_id,omitempty"`
-	Object         string `json:"object,omitempty"`
-	ObjectID       string `json:"object_id,omitempty"`
-	ChangedAspect  string `json:"changed_aspect,omitempty"`
-	Time           int64  `json:"time,omitempty"`
+	SubscriptionID int64         `json:"subscription_id,omitempty"`
+	Object         string        `json:"object,omitempty"`
+	ObjectID       string        `json:"object_id,omitempty"`
+	ChangedAspect  string        `json:"changed_aspect,omitempty"`
+	Time           int64         `json:"time,omitempty"`
+	Data           *RealtimeData `json:"data,omitempty"`
+}
+
+// RealtimeData holds the details sent with notifications about users.
+type RealtimeData struct {
+	MediaID string `json:"media_id,omitempty"`
 }
 
-//ListSubscriptions ists the realtime subscriptions that are already active for your account
+// UnmarshalJSON decodes a notification. Instagram has sent the subscription ID
+// both as a number and as a string, so both are accepted.
+func (r *RealtimeResponse) UnmarshalJSON(data []byte) error {
+	type response RealtimeResponse
+	aux := struct {
+		*response
+		SubscriptionID json.Number `json:"subscription_id,omitempty"`
+	}{response: (*response)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	r.SubscriptionID = 0
+	if aux.SubscriptionID != "" {
+		id, err := aux.SubscriptionID.Int64()
+		if err != nil {
+			return err
+		}
+		r.SubscriptionID = id
+	}
+	return nil
+}
+
+// ListSubscriptions lists the realtime subscriptions that are already active for your account
 func (s *RealtimeService) ListSubscriptions() ([]Realtime, error) {
+	realtime, _, err := s.ListSubscriptionsContext(context.Background())
+	return realtime, err
+}
+
+// ListSubscriptionsContext is like ListSubscriptions but stops when ctx is done
+// and returns the Response.
+func (s *RealtimeService) ListSubscriptionsContext(ctx context.Context) ([]Realtime, *Response, error) {
 	u := "subscriptions/"
 
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	realtime := new([]Realtime)
 
-	_, err = s.client.Do(req, realtime)
+	resp, err := s.client.DoContext(ctx, req, realtime)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return *realtime, err
+	return *realtime, resp, err
 }
 
-// SubscribeToTag initiates the subscription to realtime updates about tag `tag`
-//
-// Instagram API docs: http://instagram.com/developer/realtime/
-func (s *RealtimeService) SubscribeToTag(tag, callbackURL, verifyToken string) (*Realtime, error) {
-	u := "subscriptions/"
+// Objects that can be subscribed to.
+const (
+	ObjectUser      = "user"
+	ObjectTag       = "tag"
+	ObjectLocation  = "location"
+	ObjectGeography = "geography"
+)
 
-	params := url.Values{
-		"aspect":        {"media"},
-		"object":        {"tag"},
-		"object_id":     {tag},
-		"callback_url":  {callbackURL},
-		"client_id":     {s.client.ClientID},
-		"client_secret": {s.client.ClientSecret},
-		"verify_token":  {verifyToken},
-	}
+// AspectMedia is the aspect of an object notified when media are posted; the
+// only one supported by Instagram.
+const AspectMedia = "media"
 
-	req, err := s.client.NewRequest("POST", u, params.Encode())
-	if err != nil {
-		return nil, err
-	}
+// SubscriptionRequest describes a realtime subscription to create.
+type SubscriptionRequest struct {
+	// Object is one of ObjectUser, ObjectTag, ObjectLocation and ObjectGeography.
+	Object string
 
-	realtime := new(Realtime)
+	// ObjectID is the tag name or location ID. It is empty for users, which
+	// stand for all the users who authorized the application, and geographies.
+	ObjectID string
 
-	_, err = s.client.Do(req, realtime)
-	if err != nil {
-		return nil, err
+	// Aspect is the aspect to be notified of. AspectMedia is used when empty.
+	Aspect string
+
+	// Lat, Lng and Radius (in meters) delimit the area of geographies.
+	Lat    float64
+	Lng    float64
+	Radius int
+
+	// CallbackURL receives the notifications.
+	CallbackURL string
+
+	// VerifyToken is sent back when Instagram verifies the callback URL.
+	VerifyToken string
+}
+
+// params returns the form values creating the subscription r.
+func (r *SubscriptionRequest) params() (url.Values, error) {
+	switch r.Object {
+	case ObjectTag, ObjectLocation:
+		if r.ObjectID == "" {
+			return nil, fmt.Errorf("instagram: %s subscription needs an ObjectID", r.Object)
+		}
+	case ObjectUser, ObjectGeography:
+	default:
+		return nil, fmt.Errorf("instagram: unknown subscription object %q", r.Object)
+	}
+	if r.CallbackURL == "" {
+		return nil, fmt.Errorf("instagram: subscription needs a CallbackURL")
 	}
 
-	return realtime, err
+	aspect := r.Aspect
+	if aspect == "" {
+		aspect = AspectMedia
+	}
+
+	params := url.Values{
+		"aspect":       {aspect},
+		"object":       {r.Object},
+		"callback_url": {r.CallbackURL},
+		"verify_token": {r.VerifyToken},
+	}
+	if r.ObjectID != "" {
+		params.Set("object_id", r.ObjectID)
+	}
+	if r.Object == ObjectGeography {
+		params.Set("lat", strconv.FormatFloat(r.Lat, 'f', -1, 64))
+		params.Set("lng", strconv.FormatFloat(r.Lng, 'f', -1, 64))
+		params.Set("radius", strconv.Itoa(r.Radius))
+	}
+	return params, nil
 }
 
-// SubscribeToLocation initiates the subscription to realtime updates about location `locationId`
+// Subscribe creates the realtime subscription described by sr. Instagram
+// verifies the callback URL before answering, so it must be reachable and
+// serve the verification, e.g. with RealtimeHandler.
 //
 // Instagram API docs: http://instagram.com/developer/realtime/
-func (s *RealtimeService) SubscribeToLocation(locationId, callbackURL, verifyToken string) (*Realtime, error) {
+func (s *RealtimeService) Subscribe(sr *SubscriptionRequest) (*Realtime, error) {
+	realtime, _, err := s.SubscribeContext(context.Background(), sr)
+	return realtime, err
+}
+
+// SubscribeContext is like Subscribe but stops when ctx is done and returns the
+// Response.
+func (s *RealtimeService) SubscribeContext(ctx context.Context, sr *SubscriptionRequest) (*Realtime, *Response, error) {
 	u := "subscriptions/"
 
-	params := url.Values{
-		"aspect":        {"media"},
-		"object":        {"location"},
-		"object_id":     {locationId},
-		"callback_url":  {callbackURL},
-		"client_id":     {s.client.ClientID},
-		"client_secret": {s.client.ClientSecret},
-		"verify_token":  {verifyToken},
+	params, err := sr.params()
+	if err != nil {
+		return nil, nil, err
 	}
+	params.Set("client_id", s.client.ClientID)
+	params.Set("client_secret", s.client.ClientSecret)
 
-	req, err := s.client.NewRequest("POST", u, params.Encode())
+	req, err := s.client.NewRequestWithContext(ctx, "POST", u, params.Encode())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	realtime := new(Realtime)
 
-	_, err = s.client.Do(req, realtime)
+	resp, err := s.client.DoContext(ctx, req, realtime)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
+	return realtime, resp, err
+}
+
+// SubscribeToUser initiates the subscription to realtime updates about the
+// media posted by the users who authorized the application.
+//
+// Instagram API docs: http://instagram.com/developer/realtime/
+func (s *RealtimeService) SubscribeToUser(callbackURL, verifyToken string) (*Realtime, error) {
+	realtime, _, err := s.SubscribeToUserContext(context.Background(), callbackURL, verifyToken)
+	return realtime, err
+}
+
+// SubscribeToUserContext is like SubscribeToUser but stops when ctx is done and
+// returns the Response.
+func (s *RealtimeService) SubscribeToUserContext(ctx context.Context, callbackURL, verifyToken string) (*Realtime, *Response, error) {
+	return s.SubscribeContext(ctx, &SubscriptionRequest{
+		Object:      ObjectUser,
+		CallbackURL: callbackURL,
+		VerifyToken: verifyToken,
+	})
+}
+
+// SubscribeToTag initiates the subscription to realtime updates about tag `tag`
+//
+// Instagram API docs: http://instagram.com/developer/realtime/
+func (s *RealtimeService) SubscribeToTag(tag, callbackURL, verifyToken string) (*Realtime, error) {
+	realtime, _, err := s.SubscribeToTagContext(context.Background(), tag, callbackURL, verifyToken)
+	return realtime, err
+}
+
+// SubscribeToTagContext is like SubscribeToTag but stops when ctx is done and
+// returns the Response.
+func (s *RealtimeService) SubscribeToTagContext(ctx context.Context, tag, callbackURL, verifyToken string) (*Realtime, *Response, error) {
+	return s.SubscribeContext(ctx, &SubscriptionRequest{
+		Object:      ObjectTag,
+		ObjectID:    tag,
+		CallbackURL: callbackURL,
+		VerifyToken: verifyToken,
+	})
+}
+
+// SubscribeToLocation initiates the subscription to realtime updates about location `locationId`
+//
+// Instagram API docs: http://instagram.com/developer/realtime/
+func (s *RealtimeService) SubscribeToLocation(locationId, callbackURL, verifyToken string) (*Realtime, error) {
+	realtime, _, err := s.SubscribeToLocationContext(context.Background(), locationId, callbackURL, verifyToken)
 	return realtime, err
 }
 
+// SubscribeToLocationContext is like SubscribeToLocation but stops when ctx is
+// done and returns the Response.
+func (s *RealtimeService) SubscribeToLocationContext(ctx context.Context, locationId, callbackURL, verifyToken string) (*Realtime, *Response, error) {
+	return s.SubscribeContext(ctx, &SubscriptionRequest{
+		Object:      ObjectLocation,
+		ObjectID:    locationId,
+		CallbackURL: callbackURL,
+		VerifyToken: verifyToken,
+	})
+}
+
 // SubscribeToGeography initiates the subscription to realtime updates about geography `lat,lng,radius`
 //
 // Instagram API docs: http://instagram.com/developer/realtime/
 func (s *RealtimeService) SubscribeToGeography(lat, lng string, radius int, callbackURL, verifyToken string) (*Realtime, error) {
-	u := "subscriptions/"
+	realtime, _, err := s.SubscribeToGeographyContext(context.Background(), lat, lng, radius, callbackURL, verifyToken)
+	return realtime, err
+}
 
-	params := url.Values{
-		"aspect":        {"media"},
-		"object":        {"geography"},
-		"lat":           {lat},
-		"lng":           {lng},
-		"radius":        {strconv.Itoa(radius)},
-		"callback_url":  {callbackURL},
-		"client_id":     {s.client.ClientID},
-		"client_secret": {s.client.ClientSecret},
-		"verify_token":  {verifyToken},
-	}
-
-	req, err := s.client.NewRequest("POST", u, params.Encode())
+// SubscribeToGeographyContext is like SubscribeToGeography but stops when ctx
+// is done and returns the Response.
+func (s *RealtimeService) SubscribeToGeographyContext(ctx context.Context, lat, lng string, radius int, callbackURL, verifyToken string) (*Realtime, *Response, error) {
+	latf, err := strconv.ParseFloat(lat, 64)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	realtime := new(Realtime)
-
-	_, err = s.client.Do(req, realtime)
+	lngf, err := strconv.ParseFloat(lng, 64)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return realtime, err
+	return s.SubscribeContext(ctx, &SubscriptionRequest{
+		Object:      ObjectGeography,
+		Lat:         latf,
+		Lng:         lngf,
+		Radius:      radius,
+		CallbackURL: callbackURL,
+		VerifyToken: verifyToken,
+	})
 }
 
 // DeleteAllSubscriptions deletes all active subscriptions for an account.
 //
 // Instagram API docs: http://instagram.com/developer/realtime/
-func (s *RealtimeService) DeleteAllSubscriptions() (*Realtime, error) {
+func (s *RealtimeService) DeleteAllSubscriptions() error {
+	_, err := s.DeleteAllSubscriptionsContext(context.Background())
+	return err
+}
+
+// DeleteAllSubscriptionsContext is like DeleteAllSubscriptions but stops when
+// ctx is done and returns the Response.
+func (s *RealtimeService) DeleteAllSubscriptionsContext(ctx context.Context) (*Response, error) {
+	return s.deleteSubscriptions(ctx, url.Values{"object": {"all"}})
+}
+
+// UnsubscribeFrom unsubscribes you from a specific subscription.
+//
+// Instagram API docs: http://instagram.com/developer/realtime/
+func (s *RealtimeService) UnsubscribeFrom(sid string) error {
+	_, err := s.UnsubscribeFromContext(context.Background(), sid)
+	return err
+}
+
+// UnsubscribeFromContext is like UnsubscribeFrom but stops when ctx is done and
+// returns the Response.
+func (s *RealtimeService) UnsubscribeFromContext(ctx context.Context, sid string) (*Response, error) {
+	return s.deleteSubscriptions(ctx, url.Values{"id": {sid}})
+}
+
+// deleteSubscriptions deletes the subscriptions selected by params. Instagram
+// answers with no data.
+func (s *RealtimeService) deleteSubscriptions(ctx context.Context, params url.Values) (*Response, error) {
 	u := "subscriptions/"
 
-	params := url.Values{
-		"object":        {"all"},
-		"client_id":     {s.client.ClientID},
-		"client_secret": {s.client.ClientSecret},
-	}
+	params.Set("client_id", s.client.ClientID)
+	params.Set("client_secret", s.client.ClientSecret)
 
 	u += "?" + params.Encode()
 
-	req, err := s.client.NewRequest("DELETE", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "DELETE", u, "")
 	if err != nil {
 		return nil, err
 	}
 
-	realtime := new(Realtime)
+	return s.client.DoContext(ctx, req, nil)
+}
 
-	_, err = s.client.Do(req, realtime)
-	if err != nil {
-		return nil, err
-	}
+// ServeInstagramRealtimeSubscribe - an example RealTimeSubscribe ResponseWriter. This can be plugged directly into
+// any standard http server. Note, however, that this particular implementation does
+// no checking that the verifyToken is correct.
+func ServeInstagramRealtimeSubscribe(w http.ResponseWriter, r *http.Request) {
+	verify := r.FormValue("hub.challenge")
 
-	return realtime, err
+	fmt.Fprint(w, verify)
 }
 
-// UnsubscribeFrom unsubscribes you from a specific subscription.
+// ComputeHubSignature returns the X-Hub-Signature Instagram sends with the
+// realtime notifications in body: its HMAC-SHA1, keyed with the client secret,
+// in hex.
+func ComputeHubSignature(body []byte, clientSecret string) string {
+	mac := hmac.New(sha1.New, []byte(clientSecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHubSignature reports whether signature is the X-Hub-Signature of body.
+func VerifyHubSignature(body []byte, signature, clientSecret string) bool {
+	return hmac.Equal([]byte(ComputeHubSignature(body, clientSecret)), []byte(signature))
+}
+
+// maxRealtimeBody limits the size of notification batches read by
+// RealtimeHandler.
+const maxRealtimeBody = 1 << 20
+
+// RealtimeHandler receives the realtime notifications of an application. It
+// can be plugged directly into any standard http server at the callback URL of
+// the subscriptions.
+//
+// GET requests are subscription verifications: hub.challenge is echoed back if
+// hub.mode is "subscribe" and hub.verify_token matches VerifyToken. POST
+// requests carry a batch of notifications; their X-Hub-Signature is checked
+// against ClientSecret before each notification is passed to the callback for
+// its object type. Callbacks are called synchronously, before Instagram gets
+// its response, so they should return quickly.
 //
 // Instagram API docs: http://instagram.com/developer/realtime/
-func (s *RealtimeService) UnsubscribeFrom(sid string) (*Realtime, error) {
-	u := "subscriptions/"
+type RealtimeHandler struct {
+	// ClientSecret of the application, used to verify notifications.
+	ClientSecret string
+
+	// VerifyToken passed when subscribing.
+	VerifyToken string
+
+	// Callbacks for the notifications about each object type. Notifications
+	// for which the callback is nil are ignored.
+	OnTag       func(n RealtimeResponse)
+	OnLocation  func(n RealtimeResponse)
+	OnGeography func(n RealtimeResponse)
+	OnUser      func(n RealtimeResponse)
+
+	// OnError, if set, is called when a request is rejected.
+	OnError func(r *http.Request, err error)
+}
 
-	params := url.Values{
-		"id":            {sid},
-		"client_id":     {s.client.ClientID},
-		"client_secret": {s.client.ClientSecret},
+// ServeHTTP handles subscription verifications and notifications.
+func (h *RealtimeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.verify(w, r)
+	case "POST":
+		h.notify(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		h.fail(w, r, fmt.Errorf("instagram: unexpected method %s", r.Method), http.StatusMethodNotAllowed)
 	}
+}
 
-	u += "?" + params.Encode()
+func (h *RealtimeHandler) verify(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
 
-	req, err := s.client.NewRequest("DELETE", u, "")
-	if err != nil {
-		return nil, err
+	if mode := q.Get("hub.mode"); mode != "subscribe" {
+		h.fail(w, r, fmt.Errorf("instagram: unexpected hub.mode %q", mode), http.StatusBadRequest)
+		return
+	}
+	if !hmac.Equal([]byte(q.Get("hub.verify_token")), []byte(h.VerifyToken)) {
+		h.fail(w, r, fmt.Errorf("instagram: hub.verify_token does not match"), http.StatusForbidden)
+		return
 	}
 
-	realtime := new(Realtime)
+	fmt.Fprint(w, q.Get("hub.challenge"))
+}
 
-	_, err = s.client.Do(req, realtime)
+func (h *RealtimeHandler) notify(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRealtimeBody))
 	if err != nil {
-		return nil, err
+		h.fail(w, r, err, http.StatusBadRequest)
+		return
 	}
 
-	return realtime, err
-}
+	if h.ClientSecret == "" || !VerifyHubSignature(body, r.Header.Get("X-Hub-Signature"), h.ClientSecret) {
+		h.fail(w, r, fmt.Errorf("instagram: invalid X-Hub-Signature"), http.StatusForbidden)
+		return
+	}
 
-// ServeInstagramRealtimeSubscribe - an example RealTimeSubscribe ResponseWriter. This can be plugged directly into
-// any standard http server. Note, however, that this particular implementation does
-// no checking that the verifyToken is correct.
-func ServeInstagramRealtimeSubscribe(w http.ResponseWriter, r *http.Request) {
-	verify := r.FormValue("hub.challenge")
+	var batch []RealtimeResponse
+	if err := json.Unmarshal(body, &batch); err != nil {
+		h.fail(w, r, err, http.StatusBadRequest)
+		return
+	}
 
-	fmt.Fprintf(w, verify)
+	for _, n := range batch {
+		var fn func(RealtimeResponse)
+		switch n.Object {
+		case ObjectTag:
+			fn = h.OnTag
+		case ObjectLocation:
+			fn = h.OnLocation
+		case ObjectGeography:
+			fn = h.OnGeography
+		case ObjectUser:
+			fn = h.OnUser
+		}
+		if fn != nil {
+			fn(n)
+		}
+	}
+}
+
+func (h *RealtimeHandler) fail(w http.ResponseWriter, r *http.Request, err error, status int) {
+	if h.OnError != nil {
+		h.OnError(r, err)
+	}
+	http.Error(w, http.StatusText(status), status)
 }
@@ -0,0 +1,214 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRealtimeHandler_verify(t *testing.T) {
+	h := &RealtimeHandler{VerifyToken: "vt"}
+
+	tests := []struct {
+		query  string
+		status int
+		body   string
+	}{
+		{"hub.mode=subscribe&hub.verify_token=vt&hub.challenge=15f7d1a91c1f40f8a748fd134752feb3", http.StatusOK, "15f7d1a91c1f40f8a748fd134752feb3"},
+		{"hub.mode=subscribe&hub.verify_token=bad&hub.challenge=x", http.StatusForbidden, ""},
+		{"hub.mode=unsubscribe&hub.verify_token=vt&hub.challenge=x", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/callback?"+tt.query, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("RealtimeHandler GET ?%s status = %d, want %d", tt.query, w.Code, tt.status)
+		}
+		if tt.status == http.StatusOK && w.Body.String() != tt.body {
+			t.Errorf("RealtimeHandler GET ?%s body = %q, want %q", tt.query, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestRealtimeHandler_notify(t *testing.T) {
+	var tags, users []RealtimeResponse
+	h := &RealtimeHandler{
+		ClientSecret: "secret",
+		OnTag:        func(n RealtimeResponse) { tags = append(tags, n) },
+		OnUser:       func(n RealtimeResponse) { users = append(users, n) },
+	}
+
+	body := `[
+		{"subscription_id": 1, "object": "tag", "object_id": "nofilter", "changed_aspect": "media", "time": 1297286541},
+		{"subscription_id": "2", "object": "user", "object_id": "1234", "changed_aspect": "media", "time": 1297286542, "data": {"media_id": "99_1234"}},
+		{"subscription_id": 3, "object": "location", "object_id": "1", "changed_aspect": "media", "time": 1297286543}
+	]`
+
+	r := httptest.NewRequest("POST", "/callback", strings.NewReader(body))
+	r.Header.Set("X-Hub-Signature", ComputeHubSignature([]byte(body), "secret"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("RealtimeHandler POST status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	wantTags := []RealtimeResponse{{SubscriptionID: 1, Object: "tag", ObjectID: "nofilter", ChangedAspect: "media", Time: 1297286541}}
+	if !reflect.DeepEqual(tags, wantTags) {
+		t.Errorf("RealtimeHandler passed tags %+v, want %+v", tags, wantTags)
+	}
+
+	wantUsers := []RealtimeResponse{{SubscriptionID: 2, Object: "user", ObjectID: "1234", ChangedAspect: "media", Time: 1297286542, Data: &RealtimeData{MediaID: "99_1234"}}}
+	if !reflect.DeepEqual(users, wantUsers) {
+		t.Errorf("RealtimeHandler passed users %+v, want %+v", users, wantUsers)
+	}
+}
+
+func TestRealtimeHandler_badSignature(t *testing.T) {
+	called := false
+	h := &RealtimeHandler{
+		ClientSecret: "secret",
+		OnTag:        func(n RealtimeResponse) { called = true },
+	}
+
+	body := `[{"object": "tag", "object_id": "nofilter"}]`
+	for _, sig := range []string{"", "deadbeef", ComputeHubSignature([]byte(body), "other")} {
+		r := httptest.NewRequest("POST", "/callback", strings.NewReader(body))
+		r.Header.Set("X-Hub-Signature", sig)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("RealtimeHandler POST with signature %q status = %d, want %d", sig, w.Code, http.StatusForbidden)
+		}
+	}
+	if called {
+		t.Errorf("RealtimeHandler dispatched a notification with a bad signature")
+	}
+}
+
+func TestRealtimeService_ListSubscriptions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"data": [{"id": "1", "object": "tag", "object_id": "nofilter", "aspect": "media", "type": "subscription"}]}`)
+	})
+
+	subs, err := client.Realtime.ListSubscriptions()
+	if err != nil {
+		t.Errorf("Realtime.ListSubscriptions returned error: %v", err)
+	}
+
+	want := []Realtime{{ID: "1", Type: "subscription", Object: "tag", ObjectID: "nofilter", Aspect: "media"}}
+	if !reflect.DeepEqual(subs, want) {
+		t.Errorf("Realtime.ListSubscriptions returned %+v, want %+v", subs, want)
+	}
+}
+
+func TestRealtimeService_Subscribe(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.ClientID = "cid"
+	client.ClientSecret = "secret"
+
+	tests := []struct {
+		subscribe func() (*Realtime, error)
+		want      values
+	}{
+		{
+			func() (*Realtime, error) { return client.Realtime.SubscribeToUser("http://cb", "vt") },
+			values{"object": "user", "object_id": "", "aspect": "media", "callback_url": "http://cb", "verify_token": "vt"},
+		},
+		{
+			func() (*Realtime, error) { return client.Realtime.SubscribeToTag("nofilter", "http://cb", "vt") },
+			values{"object": "tag", "object_id": "nofilter", "aspect": "media"},
+		},
+		{
+			func() (*Realtime, error) { return client.Realtime.SubscribeToLocation("1257285", "http://cb", "vt") },
+			values{"object": "location", "object_id": "1257285"},
+		},
+		{
+			func() (*Realtime, error) {
+				return client.Realtime.SubscribeToGeography("35.657872", "139.70232", 1000, "http://cb", "vt")
+			},
+			values{"object": "geography", "lat": "35.657872", "lng": "139.70232", "radius": "1000"},
+		},
+	}
+
+	var got values
+	mux.HandleFunc("/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testFormValues(t, r, values{"client_id": "cid", "client_secret": "secret"})
+		testFormValues(t, r, got)
+		fmt.Fprintf(w, `{"data": {"id": "1", "object": %q}}`, r.FormValue("object"))
+	})
+
+	for _, tt := range tests {
+		got = tt.want
+		sub, err := tt.subscribe()
+		if err != nil {
+			t.Errorf("subscribing to %s returned error: %v", tt.want["object"], err)
+			continue
+		}
+		if want := (&Realtime{ID: "1", Object: tt.want["object"]}); !reflect.DeepEqual(sub, want) {
+			t.Errorf("subscribing to %s returned %+v, want %+v", tt.want["object"], sub, want)
+		}
+	}
+}
+
+func TestRealtimeService_Subscribe_invalid(t *testing.T) {
+	c := NewClient(nil)
+
+	for _, sr := range []*SubscriptionRequest{
+		{Object: "media", CallbackURL: "http://cb"},
+		{Object: ObjectTag, CallbackURL: "http://cb"},
+		{Object: ObjectUser},
+	} {
+		if _, err := c.Realtime.Subscribe(sr); err == nil {
+			t.Errorf("Realtime.Subscribe(%+v) returned no error", sr)
+		}
+	}
+}
+
+func TestRealtimeService_UnsubscribeFrom(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		testFormValues(t, r, values{"id": "7"})
+		fmt.Fprint(w, `{"meta": {"code": 200}, "data": null}`)
+	})
+
+	if err := client.Realtime.UnsubscribeFrom("7"); err != nil {
+		t.Errorf("Realtime.UnsubscribeFrom returned error: %v", err)
+	}
+}
+
+func TestRealtimeService_DeleteAllSubscriptions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		testFormValues(t, r, values{"object": "all"})
+		fmt.Fprint(w, `{"meta": {"code": 200}, "data": null}`)
+	})
+
+	if err := client.Realtime.DeleteAllSubscriptions(); err != nil {
+		t.Errorf("Realtime.DeleteAllSubscriptions returned error: %v", err)
+	}
+}
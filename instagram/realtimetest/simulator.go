@@ -0,0 +1,328 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package realtimetest simulates Instagram's realtime API, for testing the
+// consumers of realtime notifications without Instagram having to reach them.
+package realtimetest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+// Faults lists the delivery problems a Simulator reproduces.
+type Faults struct {
+	// Duplicate sends every notification of a batch twice.
+	Duplicate bool
+
+	// Reorder shuffles the notifications of a batch.
+	Reorder bool
+
+	// BadSignature signs the batches with a wrong secret.
+	BadSignature bool
+}
+
+// Simulator plays Instagram's part in realtime subscriptions: it verifies
+// callback URLs with the hub.challenge handshake when subscribing, and sends
+// signed batches of notifications to the subscribed callback URLs.
+//
+// A Simulator also serves the subscriptions endpoint of the API, so that a
+// Client whose BaseURL points at an httptest.Server running it can subscribe
+// with RealtimeService:
+//
+//	sim := realtimetest.NewSimulator("secret")
+//	srv := httptest.NewServer(sim)
+//	client.BaseURL, _ = url.Parse(srv.URL + "/")
+//
+// A Simulator is safe for concurrent use.
+type Simulator struct {
+	// ClientSecret signs the notifications.
+	ClientSecret string
+
+	// Faults applies to every batch sent.
+	Faults Faults
+
+	// HTTPClient sends the requests to the callback URLs. http.DefaultClient
+	// is used when nil.
+	HTTPClient *http.Client
+
+	// Rand shuffles reordered batches. A source seeded with the current time
+	// is used when nil.
+	Rand *rand.Rand
+
+	mu     sync.Mutex
+	subs   []instagram.Realtime
+	nextID int
+}
+
+// NewSimulator returns a Simulator signing with clientSecret.
+func NewSimulator(clientSecret string) *Simulator {
+	return &Simulator{ClientSecret: clientSecret}
+}
+
+func (s *Simulator) client() *http.Client {
+	if s.HTTPClient != nil {
+		return s.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Subscribe verifies the callback URL of sr the way Instagram does and, if the
+// callback echoes the challenge, registers the subscription.
+func (s *Simulator) Subscribe(ctx context.Context, sr *instagram.SubscriptionRequest) (*instagram.Realtime, error) {
+	if sr.CallbackURL == "" {
+		return nil, fmt.Errorf("realtimetest: subscription needs a CallbackURL")
+	}
+	if err := s.handshake(ctx, sr.CallbackURL, sr.VerifyToken); err != nil {
+		return nil, err
+	}
+
+	aspect := sr.Aspect
+	if aspect == "" {
+		aspect = instagram.AspectMedia
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	sub := instagram.Realtime{
+		ID:          strconv.Itoa(s.nextID),
+		Type:        "subscription",
+		Object:      sr.Object,
+		ObjectID:    sr.ObjectID,
+		Aspect:      aspect,
+		CallbackURL: sr.CallbackURL,
+	}
+	if sub.Object == instagram.ObjectGeography {
+		sub.Lat, sub.Lng, sub.Radius = sr.Lat, sr.Lng, sr.Radius
+		if sub.ObjectID == "" {
+			// Instagram identifies geographies by an ID of its own.
+			sub.ObjectID = sub.ID
+		}
+	}
+	s.subs = append(s.subs, sub)
+	return &sub, nil
+}
+
+// handshake sends the verification request to callbackURL and checks that the
+// challenge is echoed back.
+func (s *Simulator) handshake(ctx context.Context, callbackURL, verifyToken string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return err
+	}
+
+	challenge := strconv.FormatInt(time.Now().UnixNano(), 36)
+	q := u.Query()
+	q.Set("hub.mode", "subscribe")
+	q.Set("hub.challenge", challenge)
+	q.Set("hub.verify_token", verifyToken)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != challenge {
+		return fmt.Errorf("realtimetest: %s failed the verification: %s", callbackURL, resp.Status)
+	}
+	return nil
+}
+
+// Subscriptions returns the registered subscriptions.
+func (s *Simulator) Subscriptions() []instagram.Realtime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]instagram.Realtime(nil), s.subs...)
+}
+
+// Unsubscribe removes the subscription with the given ID, or all of them if id
+// is empty.
+func (s *Simulator) Unsubscribe(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.subs[:0]
+	for _, sub := range s.subs {
+		if id != "" && sub.ID != id {
+			subs = append(subs, sub)
+		}
+	}
+	s.subs = subs
+}
+
+// Notify sends notifications about objects to the callback URLs subscribed to
+// them, in one batch per callback URL. Each notification needs Object and
+// ObjectID; its SubscriptionID, ChangedAspect and Time are filled in. User
+// subscriptions cover every user and geography ones match on their ID.
+func (s *Simulator) Notify(ctx context.Context, notifications ...instagram.RealtimeResponse) error {
+	var callbacks []string
+	batches := make(map[string][]instagram.RealtimeResponse)
+
+	s.mu.Lock()
+	for _, n := range notifications {
+		for _, sub := range s.subs {
+			if sub.Object != n.Object || (sub.Object != instagram.ObjectUser && sub.ObjectID != n.ObjectID) {
+				continue
+			}
+
+			n := n
+			n.SubscriptionID, _ = strconv.ParseInt(sub.ID, 10, 64)
+			n.ChangedAspect = sub.Aspect
+			if n.Time == 0 {
+				n.Time = time.Now().Unix()
+			}
+
+			if _, ok := batches[sub.CallbackURL]; !ok {
+				callbacks = append(callbacks, sub.CallbackURL)
+			}
+			batches[sub.CallbackURL] = append(batches[sub.CallbackURL], n)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, cb := range callbacks {
+		if err := s.Send(ctx, cb, batches[cb]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send posts batch to callbackURL as Instagram does, applying Faults. An error
+// is returned if the callback does not answer with a 2xx status.
+func (s *Simulator) Send(ctx context.Context, callbackURL string, batch []instagram.RealtimeResponse) error {
+	batch = s.applyFaults(batch)
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	secret := s.ClientSecret
+	if s.Faults.BadSignature {
+		secret += "-wrong"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hub-Signature", instagram.ComputeHubSignature(body, secret))
+
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("realtimetest: %s answered %s", callbackURL, resp.Status)
+	}
+	return nil
+}
+
+func (s *Simulator) applyFaults(batch []instagram.RealtimeResponse) []instagram.RealtimeResponse {
+	batch = append([]instagram.RealtimeResponse(nil), batch...)
+
+	if s.Faults.Duplicate {
+		batch = append(batch, batch...)
+	}
+
+	if s.Faults.Reorder {
+		s.mu.Lock()
+		if s.Rand == nil {
+			s.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
+		s.Rand.Shuffle(len(batch), func(i, j int) {
+			batch[i], batch[j] = batch[j], batch[i]
+		})
+		s.mu.Unlock()
+	}
+
+	return batch
+}
+
+// ServeHTTP serves the subscriptions endpoint of the API: GET lists the
+// subscriptions, POST creates one and DELETE removes those selected by the id
+// or object parameter.
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var data interface{}
+
+	switch r.Method {
+	case "GET":
+		data = s.Subscriptions()
+	case "POST":
+		radius, _ := strconv.Atoi(r.FormValue("radius"))
+		lat, _ := strconv.ParseFloat(r.FormValue("lat"), 64)
+		lng, _ := strconv.ParseFloat(r.FormValue("lng"), 64)
+		sub, err := s.Subscribe(r.Context(), &instagram.SubscriptionRequest{
+			Object:      r.FormValue("object"),
+			ObjectID:    r.FormValue("object_id"),
+			Aspect:      r.FormValue("aspect"),
+			Lat:         lat,
+			Lng:         lng,
+			Radius:      radius,
+			CallbackURL: r.FormValue("callback_url"),
+			VerifyToken: r.FormValue("verify_token"),
+		})
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "APISubscriptionError", err.Error())
+			return
+		}
+		data = sub
+	case "DELETE":
+		q := r.URL.Query()
+		switch {
+		case q.Get("id") != "":
+			s.Unsubscribe(q.Get("id"))
+		case q.Get("object") == "all":
+			s.Unsubscribe("")
+		default:
+			writeError(w, http.StatusBadRequest, "APIInvalidParametersError", "missing id or object")
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "APIError", "unsupported method "+r.Method)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"meta": map[string]int{"code": http.StatusOK},
+		"data": data,
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, errorType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"meta": instagram.ResponseMeta{Code: status, ErrorType: errorType, ErrorMessage: message},
+	})
+}
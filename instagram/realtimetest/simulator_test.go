@@ -0,0 +1,142 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package realtimetest
+
+import (
+	"context"
+	"math/rand"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/carbocation/go-instagram/instagram"
+)
+
+// consumer is a webhook consumer recording the notifications it receives.
+type consumer struct {
+	mu       sync.Mutex
+	received []string
+	srv      *httptest.Server
+}
+
+func newConsumer(secret string) *consumer {
+	c := new(consumer)
+	record := func(n instagram.RealtimeResponse) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.received = append(c.received, n.Object+":"+n.ObjectID)
+	}
+	c.srv = httptest.NewServer(&instagram.RealtimeHandler{
+		ClientSecret: secret,
+		VerifyToken:  "token",
+		OnTag:        record,
+		OnUser:       record,
+	})
+	return c
+}
+
+func setup() (*Simulator, *instagram.Client, *consumer, func()) {
+	sim := NewSimulator("secret")
+	api := httptest.NewServer(sim)
+
+	client := instagram.NewClient(nil)
+	client.BaseURL, _ = url.Parse(api.URL + "/")
+
+	c := newConsumer("secret")
+	return sim, client, c, func() {
+		api.Close()
+		c.srv.Close()
+	}
+}
+
+func TestSimulator(t *testing.T) {
+	sim, client, c, teardown := setup()
+	defer teardown()
+
+	ctx := context.Background()
+	for _, sr := range []instagram.SubscriptionRequest{
+		{Object: instagram.ObjectTag, ObjectID: "go", CallbackURL: c.srv.URL, VerifyToken: "token"},
+		{Object: instagram.ObjectUser, CallbackURL: c.srv.URL, VerifyToken: "token"},
+	} {
+		if _, _, err := client.Realtime.SubscribeContext(ctx, &sr); err != nil {
+			t.Fatalf("Subscribe returned error: %v", err)
+		}
+	}
+
+	if _, err := client.Realtime.Subscribe(&instagram.SubscriptionRequest{
+		Object: instagram.ObjectTag, ObjectID: "go", CallbackURL: c.srv.URL, VerifyToken: "wrong",
+	}); err == nil {
+		t.Errorf("Subscribe with a wrong verify token returned no error")
+	}
+
+	subs, err := client.Realtime.ListSubscriptions()
+	if err != nil {
+		t.Fatalf("ListSubscriptions returned error: %v", err)
+	}
+	if len(subs) != 2 {
+		t.Errorf("ListSubscriptions returned %d subscriptions, want 2", len(subs))
+	}
+
+	err = sim.Notify(ctx,
+		instagram.RealtimeResponse{Object: instagram.ObjectTag, ObjectID: "go"},
+		instagram.RealtimeResponse{Object: instagram.ObjectTag, ObjectID: "rust"},
+		instagram.RealtimeResponse{Object: instagram.ObjectUser, ObjectID: "42"},
+	)
+	if err != nil {
+		t.Fatalf("Notify returned error: %v", err)
+	}
+	if want := []string{"tag:go", "user:42"}; !reflect.DeepEqual(c.received, want) {
+		t.Errorf("consumer received %v, want %v", c.received, want)
+	}
+
+	if err := client.Realtime.UnsubscribeFrom(subs[0].ID); err != nil {
+		t.Fatalf("UnsubscribeFrom returned error: %v", err)
+	}
+	if n := len(sim.Subscriptions()); n != 1 {
+		t.Errorf("Simulator has %d subscriptions after UnsubscribeFrom, want 1", n)
+	}
+}
+
+func TestSimulator_faults(t *testing.T) {
+	sim, _, c, teardown := setup()
+	defer teardown()
+
+	ctx := context.Background()
+	for _, tag := range []string{"a", "b", "c"} {
+		sr := &instagram.SubscriptionRequest{Object: instagram.ObjectTag, ObjectID: tag, CallbackURL: c.srv.URL, VerifyToken: "token"}
+		if _, err := sim.Subscribe(ctx, sr); err != nil {
+			t.Fatalf("Subscribe returned error: %v", err)
+		}
+	}
+	notifications := []instagram.RealtimeResponse{
+		{Object: instagram.ObjectTag, ObjectID: "a"},
+		{Object: instagram.ObjectTag, ObjectID: "b"},
+		{Object: instagram.ObjectTag, ObjectID: "c"},
+	}
+
+	sim.Faults = Faults{Duplicate: true, Reorder: true}
+	sim.Rand = rand.New(rand.NewSource(1))
+	if err := sim.Notify(ctx, notifications...); err != nil {
+		t.Fatalf("Notify returned error: %v", err)
+	}
+	got := append([]string(nil), c.received...)
+	sort.Strings(got)
+	if want := []string{"tag:a", "tag:a", "tag:b", "tag:b", "tag:c", "tag:c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("consumer received %v, want %v", got, want)
+	}
+
+	c.received = nil
+	sim.Faults = Faults{BadSignature: true}
+	if err := sim.Notify(ctx, notifications...); err == nil {
+		t.Errorf("Notify with a bad signature returned no error")
+	}
+	if len(c.received) != 0 {
+		t.Errorf("consumer accepted badly signed notifications %v", c.received)
+	}
+}
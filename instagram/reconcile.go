@@ -0,0 +1,178 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+)
+
+// SubscriptionReconciler brings the realtime subscriptions of an application
+// to a desired set: it creates the missing ones and deletes the ones that are
+// not wanted anymore.
+//
+// Subscriptions are identified by object, object ID, aspect and callback URL.
+// Desired geographies without an ObjectID are identified by their area
+// instead: an existing geography subscription reporting another area is
+// replaced. Instagram does not always report the area, and geographies
+// without one match any desired geography with the same callback URL and
+// aspect.
+type SubscriptionReconciler struct {
+	// Realtime is the service used to list, create and delete subscriptions.
+	Realtime RealtimeAPI
+
+	// Manages, if set, restricts the reconciler to the existing subscriptions
+	// for which it returns true, e.g. those of one environment's callback URL.
+	// Other subscriptions are never deleted.
+	Manages func(sub Realtime) bool
+}
+
+// SubscriptionPlan lists the changes made by a reconciliation.
+type SubscriptionPlan struct {
+	// Create lists the subscriptions to create.
+	Create []SubscriptionRequest
+
+	// Delete lists the subscriptions to delete.
+	Delete []Realtime
+
+	// Keep lists the existing subscriptions that are desired.
+	Keep []Realtime
+}
+
+// Empty reports whether the plan changes nothing.
+func (p *SubscriptionPlan) Empty() bool {
+	return len(p.Create) == 0 && len(p.Delete) == 0
+}
+
+// String describes the plan, one line per subscription, prefixed with "+"
+// for creations, "-" for deletions and " " for unchanged subscriptions.
+func (p *SubscriptionPlan) String() string {
+	var b bytes.Buffer
+	for _, sr := range p.Create {
+		fmt.Fprintf(&b, "+ %s\n", describeSubscription(sr.Object, sr.ObjectID, sr.aspect(), sr.CallbackURL))
+		if sr.Object == ObjectGeography {
+			fmt.Fprintf(&b, "    lat=%v lng=%v radius=%d\n", sr.Lat, sr.Lng, sr.Radius)
+		}
+	}
+	for _, sub := range p.Delete {
+		fmt.Fprintf(&b, "- %s (id %s)\n", describeSubscription(sub.Object, sub.ObjectID, sub.Aspect, sub.CallbackURL), sub.ID)
+	}
+	for _, sub := range p.Keep {
+		fmt.Fprintf(&b, "  %s (id %s)\n", describeSubscription(sub.Object, sub.ObjectID, sub.Aspect, sub.CallbackURL), sub.ID)
+	}
+	return b.String()
+}
+
+func describeSubscription(object, objectID, aspect, callbackURL string) string {
+	if objectID != "" {
+		object += " " + objectID
+	}
+	return fmt.Sprintf("%s [%s] -> %s", object, aspect, callbackURL)
+}
+
+// aspect returns the aspect the request subscribes to.
+func (r *SubscriptionRequest) aspect() string {
+	if r.Aspect == "" {
+		return AspectMedia
+	}
+	return r.Aspect
+}
+
+// subscriptionKey identifies a subscription when comparing desired and
+// existing ones.
+type subscriptionKey struct {
+	object, objectID, aspect, callbackURL string
+}
+
+// Plan compares desired with the subscriptions Instagram reports and returns
+// the changes needed. Nothing is modified.
+func (r *SubscriptionReconciler) Plan(ctx context.Context, desired []SubscriptionRequest) (*SubscriptionPlan, error) {
+	existing, _, err := r.Realtime.ListSubscriptionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := new(SubscriptionPlan)
+	claimed := make([]bool, len(existing))
+	wanted := make(map[subscriptionKey]bool)
+
+	for _, sr := range desired {
+		key := subscriptionKey{sr.Object, sr.ObjectID, sr.aspect(), sr.CallbackURL}
+		if wanted[key] && !(sr.Object == ObjectGeography && sr.ObjectID == "") {
+			continue
+		}
+		wanted[key] = true
+
+		found := false
+		for i, sub := range existing {
+			if claimed[i] || sub.Object != sr.Object || sub.Aspect != key.aspect || sub.CallbackURL != sr.CallbackURL {
+				continue
+			}
+			if sr.Object == ObjectGeography && sr.ObjectID == "" {
+				if sub.Radius != 0 && (!sameCoordinate(sub.Lat, sr.Lat) || !sameCoordinate(sub.Lng, sr.Lng) || sub.Radius != sr.Radius) {
+					continue
+				}
+			} else if sub.ObjectID != sr.ObjectID {
+				continue
+			}
+			claimed[i] = true
+			plan.Keep = append(plan.Keep, sub)
+			found = true
+			break
+		}
+		if !found {
+			plan.Create = append(plan.Create, sr)
+		}
+	}
+
+	for i, sub := range existing {
+		if claimed[i] || (r.Manages != nil && !r.Manages(sub)) {
+			continue
+		}
+		plan.Delete = append(plan.Delete, sub)
+	}
+
+	return plan, nil
+}
+
+// Apply carries out plan, deleting subscriptions first so that Instagram's
+// limit on their number is not hit. It stops at the first error.
+func (r *SubscriptionReconciler) Apply(ctx context.Context, plan *SubscriptionPlan) error {
+	for _, sub := range plan.Delete {
+		if _, err := r.Realtime.UnsubscribeFromContext(ctx, sub.ID); err != nil {
+			return fmt.Errorf("instagram: deleting subscription %s: %w", sub.ID, err)
+		}
+	}
+
+	for i := range plan.Create {
+		sr := plan.Create[i]
+		if _, _, err := r.Realtime.SubscribeContext(ctx, &sr); err != nil {
+			return fmt.Errorf("instagram: creating subscription to %s %s: %w", sr.Object, sr.ObjectID, err)
+		}
+	}
+
+	return nil
+}
+
+// Reconcile plans the changes bringing the subscriptions to desired and, unless
+// dryRun is set, applies them. The plan is returned in both cases.
+func (r *SubscriptionReconciler) Reconcile(ctx context.Context, desired []SubscriptionRequest, dryRun bool) (*SubscriptionPlan, error) {
+	plan, err := r.Plan(ctx, desired)
+	if err != nil || dryRun {
+		return plan, err
+	}
+	return plan, r.Apply(ctx, plan)
+}
+
+// coordinateTolerance is the difference in degrees, about 10cm, below which
+// two coordinates are the same, since the API may round the ones it returns.
+const coordinateTolerance = 1e-6
+
+func sameCoordinate(a, b float64) bool {
+	return math.Abs(a-b) < coordinateTolerance
+}
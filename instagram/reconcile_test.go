@@ -0,0 +1,120 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSubscriptionReconciler_Reconcile(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var deleted, created []string
+	mux.HandleFunc("/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `{"data": [
+				{"id": "1", "object": "tag", "object_id": "keep", "aspect": "media", "callback_url": "http://cb"},
+				{"id": "2", "object": "tag", "object_id": "old", "aspect": "media", "callback_url": "http://cb"},
+				{"id": "3", "object": "location", "object_id": "9", "aspect": "media", "callback_url": "http://other"},
+				{"id": "4", "object": "geography", "object_id": "55", "aspect": "media", "callback_url": "http://cb"}
+			]}`)
+		case "DELETE":
+			deleted = append(deleted, r.FormValue("id"))
+			fmt.Fprint(w, `{"data": null}`)
+		case "POST":
+			created = append(created, r.FormValue("object")+" "+r.FormValue("object_id"))
+			fmt.Fprint(w, `{"data": {"id": "5"}}`)
+		}
+	})
+
+	rec := &SubscriptionReconciler{
+		Realtime: client.Realtime,
+		Manages:  func(sub Realtime) bool { return sub.CallbackURL == "http://cb" },
+	}
+	desired := []SubscriptionRequest{
+		{Object: ObjectTag, ObjectID: "keep", CallbackURL: "http://cb"},
+		{Object: ObjectTag, ObjectID: "new", CallbackURL: "http://cb"},
+		{Object: ObjectTag, ObjectID: "new", CallbackURL: "http://cb"},
+		{Object: ObjectGeography, Lat: 1, Lng: 2, Radius: 100, CallbackURL: "http://cb"},
+	}
+
+	plan, err := rec.Reconcile(context.Background(), desired, true)
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if deleted != nil || created != nil {
+		t.Errorf("dry-run Reconcile deleted %v and created %v", deleted, created)
+	}
+
+	want := &SubscriptionPlan{
+		Create: []SubscriptionRequest{desired[1]},
+		Delete: []Realtime{{ID: "2", Object: "tag", ObjectID: "old", Aspect: "media", CallbackURL: "http://cb"}},
+		Keep: []Realtime{
+			{ID: "1", Object: "tag", ObjectID: "keep", Aspect: "media", CallbackURL: "http://cb"},
+			{ID: "4", Object: "geography", ObjectID: "55", Aspect: "media", CallbackURL: "http://cb"},
+		},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("Reconcile returned plan %+v, want %+v", plan, want)
+	}
+
+	wantString := "+ tag new [media] -> http://cb\n" +
+		"- tag old [media] -> http://cb (id 2)\n" +
+		"  tag keep [media] -> http://cb (id 1)\n" +
+		"  geography 55 [media] -> http://cb (id 4)\n"
+	if plan.String() != wantString {
+		t.Errorf("SubscriptionPlan.String() = %q, want %q", plan.String(), wantString)
+	}
+
+	if _, err := rec.Reconcile(context.Background(), desired, false); err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"2"}) || !reflect.DeepEqual(created, []string{"tag new"}) {
+		t.Errorf("Reconcile deleted %v and created %v, want [2] and [tag new]", deleted, created)
+	}
+}
+
+func TestSubscriptionReconciler_Plan_geographyArea(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [
+			{"id": "4", "object": "geography", "object_id": "55", "aspect": "media", "callback_url": "http://cb", "lat": 1, "lng": 2, "radius": 100},
+			{"id": "5", "object": "geography", "object_id": "56", "aspect": "media", "callback_url": "http://cb", "lat": 3, "lng": 4, "radius": 100},
+			{"id": "6", "object": "geography", "object_id": "57", "aspect": "media", "callback_url": "http://cb", "lat": 48.858844, "lng": 2.294351, "radius": 100}
+		]}`)
+	})
+
+	rec := &SubscriptionReconciler{Realtime: client.Realtime}
+	desired := []SubscriptionRequest{
+		{Object: ObjectGeography, Lat: 1, Lng: 2, Radius: 200, CallbackURL: "http://cb"},
+		{Object: ObjectGeography, Lat: 3, Lng: 4, Radius: 100, CallbackURL: "http://cb"},
+		{Object: ObjectGeography, Lat: 48.8588443, Lng: 2.2943506, Radius: 100, CallbackURL: "http://cb"},
+	}
+	plan, err := rec.Plan(context.Background(), desired)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	want := &SubscriptionPlan{
+		Create: []SubscriptionRequest{desired[0]},
+		Delete: []Realtime{{ID: "4", Object: "geography", ObjectID: "55", Aspect: "media", CallbackURL: "http://cb", Lat: 1, Lng: 2, Radius: 100}},
+		Keep: []Realtime{
+			{ID: "5", Object: "geography", ObjectID: "56", Aspect: "media", CallbackURL: "http://cb", Lat: 3, Lng: 4, Radius: 100},
+			{ID: "6", Object: "geography", ObjectID: "57", Aspect: "media", CallbackURL: "http://cb", Lat: 48.858844, Lng: 2.294351, Radius: 100},
+		},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("Plan returned %+v, want %+v", plan, want)
+	}
+}
@@ -6,9 +6,8 @@
 package instagram
 
 import (
+	"context"
 	"fmt"
-	log "github.com/Sirupsen/logrus"
-	"io/ioutil"
 	"net/url"
 	"strconv"
 )
@@ -40,6 +39,16 @@ type Relationship struct {
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#get_users_follows
 func (s *RelationshipsService) Follows(userID string, opt *Parameters) ([]User, *ResponsePagination, error) {
+	users, resp, err := s.FollowsContext(context.Background(), userID, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, resp.Pagination, nil
+}
+
+// FollowsContext is like Follows but stops when ctx is done and returns the
+// Response.
+func (s *RelationshipsService) FollowsContext(ctx context.Context, userID string, opt *Parameters) ([]User, *Response, error) {
 	var u string
 	if userID != "" {
 		u = fmt.Sprintf("users/%v/follows", userID)
@@ -58,24 +67,19 @@ func (s *RelationshipsService) Follows(userID string, opt *Parameters) ([]User,
 		u += "?" + params.Encode()
 	}
 
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	users := new([]User)
 
-	_, err = s.client.Do(req, users)
+	resp, err := s.client.DoContext(ctx, req, users)
 	if err != nil {
-		return nil, nil, err
+		return nil, resp, err
 	}
 
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
-	}
-
-	return *users, page, err
+	return *users, resp, err
 }
 
 // FollowedBy gets the list of users this user is followed by. If empty string is
@@ -83,6 +87,16 @@ func (s *RelationshipsService) Follows(userID string, opt *Parameters) ([]User,
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#get_users_followed_by
 func (s *RelationshipsService) FollowedBy(userID string, opt *Parameters) ([]User, *ResponsePagination, error) {
+	users, resp, err := s.FollowedByContext(context.Background(), userID, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, resp.Pagination, nil
+}
+
+// FollowedByContext is like FollowedBy but stops when ctx is done and returns
+// the Response.
+func (s *RelationshipsService) FollowedByContext(ctx context.Context, userID string, opt *Parameters) ([]User, *Response, error) {
 	var u string
 	if userID != "" {
 		u = fmt.Sprintf("users/%v/followed-by", userID)
@@ -101,118 +115,159 @@ func (s *RelationshipsService) FollowedBy(userID string, opt *Parameters) ([]Use
 		u += "?" + params.Encode()
 	}
 
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	users := new([]User)
 
-	_, err = s.client.Do(req, users)
+	resp, err := s.client.DoContext(ctx, req, users)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
+		return nil, resp, err
 	}
 
-	return *users, page, err
+	return *users, resp, err
 }
 
 // RequestedBy lists the users who have requested this user's permission to follow.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#get_incoming_requests
 func (s *RelationshipsService) RequestedBy() ([]User, *ResponsePagination, error) {
-	u := "users/self/requested-by"
-	req, err := s.client.NewRequest("GET", u, "")
+	users, resp, err := s.RequestedByContext(context.Background())
 	if err != nil {
 		return nil, nil, err
 	}
+	return users, resp.Pagination, nil
+}
 
-	users := new([]User)
-
-	_, err = s.client.Do(req, users)
+// RequestedByContext is like RequestedBy but stops when ctx is done and returns
+// the Response.
+func (s *RelationshipsService) RequestedByContext(ctx context.Context) ([]User, *Response, error) {
+	u := "users/self/requested-by"
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
+	users := new([]User)
+
+	resp, err := s.client.DoContext(ctx, req, users)
+	if err != nil {
+		return nil, resp, err
 	}
 
-	return *users, page, err
+	return *users, resp, err
 }
 
 // Relationship gets information about a relationship to another user.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#get_relationship
 func (s *RelationshipsService) Relationship(userID string) (*Relationship, error) {
-	return relationshipAction(s, userID, "", "GET")
+	rel, _, err := s.RelationshipContext(context.Background(), userID)
+	return rel, err
+}
+
+// RelationshipContext is like Relationship but stops when ctx is done and
+// returns the Response.
+func (s *RelationshipsService) RelationshipContext(ctx context.Context, userID string) (*Relationship, *Response, error) {
+	return relationshipAction(ctx, s, userID, "", "GET")
 }
 
 // Follow a user.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#post_relationship
 func (s *RelationshipsService) Follow(userID string) (*Relationship, error) {
-	return relationshipAction(s, userID, "follow", "POST")
+	rel, _, err := s.FollowContext(context.Background(), userID)
+	return rel, err
+}
+
+// FollowContext is like Follow but stops when ctx is done and returns the
+// Response.
+func (s *RelationshipsService) FollowContext(ctx context.Context, userID string) (*Relationship, *Response, error) {
+	return relationshipAction(ctx, s, userID, "follow", "POST")
 }
 
 // Unfollow a user.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#post_relationship
 func (s *RelationshipsService) Unfollow(userID string) (*Relationship, error) {
-	return relationshipAction(s, userID, "unfollow", "POST")
+	rel, _, err := s.UnfollowContext(context.Background(), userID)
+	return rel, err
+}
+
+// UnfollowContext is like Unfollow but stops when ctx is done and returns the
+// Response.
+func (s *RelationshipsService) UnfollowContext(ctx context.Context, userID string) (*Relationship, *Response, error) {
+	return relationshipAction(ctx, s, userID, "unfollow", "POST")
 }
 
 // Block a user.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#post_relationship
 func (s *RelationshipsService) Block(userID string) (*Relationship, error) {
-	return relationshipAction(s, userID, "block", "POST")
+	rel, _, err := s.BlockContext(context.Background(), userID)
+	return rel, err
+}
+
+// BlockContext is like Block but stops when ctx is done and returns the
+// Response.
+func (s *RelationshipsService) BlockContext(ctx context.Context, userID string) (*Relationship, *Response, error) {
+	return relationshipAction(ctx, s, userID, "block", "POST")
 }
 
 // Unblock a user.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#post_relationship
 func (s *RelationshipsService) Unblock(userID string) (*Relationship, error) {
-	return relationshipAction(s, userID, "unblock", "POST")
+	rel, _, err := s.UnblockContext(context.Background(), userID)
+	return rel, err
+}
+
+// UnblockContext is like Unblock but stops when ctx is done and returns the
+// Response.
+func (s *RelationshipsService) UnblockContext(ctx context.Context, userID string) (*Relationship, *Response, error) {
+	return relationshipAction(ctx, s, userID, "unblock", "POST")
 }
 
 // Approve a user.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#post_relationship
 func (s *RelationshipsService) Approve(userID string) (*Relationship, error) {
-	return relationshipAction(s, userID, "approve", "POST")
+	rel, _, err := s.ApproveContext(context.Background(), userID)
+	return rel, err
+}
+
+// ApproveContext is like Approve but stops when ctx is done and returns the
+// Response.
+func (s *RelationshipsService) ApproveContext(ctx context.Context, userID string) (*Relationship, *Response, error) {
+	return relationshipAction(ctx, s, userID, "approve", "POST")
 }
 
 // Deny a user.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/relationships/#post_relationship
 func (s *RelationshipsService) Deny(userID string) (*Relationship, error) {
-	return relationshipAction(s, userID, "deny", "POST")
+	rel, _, err := s.DenyContext(context.Background(), userID)
+	return rel, err
+}
+
+// DenyContext is like Deny but stops when ctx is done and returns the Response.
+func (s *RelationshipsService) DenyContext(ctx context.Context, userID string) (*Relationship, *Response, error) {
+	return relationshipAction(ctx, s, userID, "deny", "POST")
 }
 
-func relationshipAction(s *RelationshipsService, userID, action, method string) (*Relationship, error) {
+func relationshipAction(ctx context.Context, s *RelationshipsService, userID, action, method string) (*Relationship, *Response, error) {
 	u := fmt.Sprintf("users/%v/relationship", userID)
 	if action != "" {
 		action = "action=" + action
 	}
-	req, err := s.client.NewRequest(method, u, action)
+	req, err := s.client.NewRequestWithContext(ctx, method, u, action)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rel := new(Relationship)
-	res, err := s.client.Do(req, rel)
-
-	data, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		log.Error(readErr)
-	}
-	log.Errorf("%+v\n", data)
-
-	return rel, err
+	resp, err := s.client.DoContext(ctx, req, rel)
+	return rel, resp, err
 }
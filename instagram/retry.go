@@ -0,0 +1,163 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Defaults used by RetryPolicy for zero fields.
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryMinBackoff  = 500 * time.Millisecond
+	DefaultRetryMaxBackoff  = 30 * time.Second
+)
+
+// RetryPolicy describes how requests failing with a transient error are
+// retried: network errors, 429 Too Many Requests and 5xx responses, among them
+// the plain-text "Oops, an error occurred." Instagram sometimes answers with.
+//
+// Attempts are separated by an exponential backoff with jitter, unless the
+// response carries a Retry-After header, which is honoured up to MaxBackoff. A
+// RetryPolicy is enabled by setting it on Client.Retry.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// DefaultRetryMaxAttempts is used when zero.
+	MaxAttempts int
+
+	// MinBackoff is the delay before the first retry, doubled on each
+	// following one. DefaultRetryMinBackoff is used when zero.
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the backoff delay, including the one asked by a
+	// Retry-After header. DefaultRetryMaxBackoff is used when zero.
+	MaxBackoff time.Duration
+
+	// RetryNonIdempotent enables retrying POST requests, such as comments or
+	// follows, which might then be applied twice.
+	RetryNonIdempotent bool
+}
+
+// NewRetryPolicy returns a RetryPolicy with the default settings.
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: DefaultRetryMaxAttempts,
+		MinBackoff:  DefaultRetryMinBackoff,
+		MaxBackoff:  DefaultRetryMaxBackoff,
+	}
+}
+
+// retry reports whether req should be sent again after attempt attempts
+// ended with resp and err. A nil policy never retries.
+func (p *RetryPolicy) retry(attempt int, req *http.Request, resp *http.Response, err error) bool {
+	if p == nil {
+		return false
+	}
+
+	max := p.MaxAttempts
+	if max == 0 {
+		max = DefaultRetryMaxAttempts
+	}
+	if attempt >= max {
+		return false
+	}
+
+	if !p.RetryNonIdempotent && !idempotent(req.Method) {
+		return false
+	}
+
+	// The body must be sent again, which is only possible if it can be
+	// obtained anew.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns how long to wait before sending the request again after
+// attempt attempts, the last one answered by resp, which may be nil.
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	min, max := p.MinBackoff, p.MaxBackoff
+	if min == 0 {
+		min = DefaultRetryMinBackoff
+	}
+	if max == 0 {
+		max = DefaultRetryMaxBackoff
+	}
+
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if d > max {
+				d = max
+			}
+			return d
+		}
+	}
+
+	d := min
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	// Equal jitter: keep half of the delay, randomize the other half.
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// sleep waits for d or until ctx is done, in which case ctx's error is
+// returned.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_Retry(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.Retry = &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+
+	calls := 0
+	mux.HandleFunc("/users/self", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "Oops, an error occurred.")
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"code": 429, "error_type": "OAuthRateLimitException", "error_message": "slow down"}`)
+		default:
+			fmt.Fprint(w, `{"data":{"id": "1"}}`)
+		}
+	})
+
+	user, err := client.Users.Get("")
+	if err != nil {
+		t.Fatalf("Users.Get returned error: %v", err)
+	}
+	if user.ID != "1" || calls != 3 {
+		t.Errorf("Users.Get returned %+v after %d calls, want user 1 after 3 calls", user, calls)
+	}
+}
+
+func TestClient_Retry_maxAttempts(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.Retry = &RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}
+
+	calls := 0
+	mux.HandleFunc("/users/self", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if _, err := client.Users.Get(""); err == nil {
+		t.Errorf("Users.Get returned no error, want one")
+	}
+	if calls != 2 {
+		t.Errorf("Users.Get made %d calls, want 2", calls)
+	}
+}
+
+func TestClient_Retry_nonIdempotent(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.Retry = &RetryPolicy{MinBackoff: time.Millisecond}
+
+	var calls int
+	var bodies []string
+	mux.HandleFunc("/media/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		bodies = append(bodies, r.FormValue("text"))
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	client.Comments.Add("1", []string{"hi"})
+	if calls != 1 {
+		t.Errorf("Comments.Add made %d calls, want 1", calls)
+	}
+
+	calls, bodies = 0, nil
+	client.Retry.RetryNonIdempotent = true
+	client.Comments.Add("1", []string{"hi"})
+	if calls != DefaultRetryMaxAttempts {
+		t.Errorf("Comments.Add with RetryNonIdempotent made %d calls, want %d", calls, DefaultRetryMaxAttempts)
+	}
+	for _, b := range bodies {
+		if b != "hi" {
+			t.Errorf("retried request has text %q, want %q", b, "hi")
+		}
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	for attempt, max := range []time.Duration{100, 200, 300, 300} {
+		max *= time.Millisecond
+		d := p.backoff(attempt+1, nil)
+		if d < max/2 || d > max {
+			t.Errorf("backoff(%d) = %v, want between %v and %v", attempt+1, d, max/2, max)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"7"}}}
+	if d := p.backoff(1, resp); d != 300*time.Millisecond {
+		t.Errorf("backoff with Retry-After: 7 = %v, want MaxBackoff", d)
+	}
+	p.MaxBackoff = 10 * time.Second
+	if d := p.backoff(1, resp); d != 7*time.Second {
+		t.Errorf("backoff with Retry-After: 7 = %v, want 7s", d)
+	}
+}
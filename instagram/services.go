@@ -0,0 +1,167 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import "context"
+
+// The interfaces below list the methods of the services hung off Client. The
+// Client fields have these types so that code depending on a service can be
+// tested with a mock in place of the service:
+//
+//	type fakeTags struct{ instagram.TagsAPI }
+//
+//	func (fakeTags) RecentMediaContext(ctx context.Context, tag string, opt *instagram.Parameters) ([]instagram.Media, *instagram.Response, error) {
+//		return []instagram.Media{{ID: "1"}}, &instagram.Response{}, nil
+//	}
+//
+//	func (f fakeTags) RecentMediaIterator(tag string, opt *instagram.Parameters) *instagram.MediaIterator {
+//		return instagram.NewMediaIterator(opt, func(ctx context.Context, opt *instagram.Parameters) ([]instagram.Media, *instagram.Response, error) {
+//			return f.RecentMediaContext(ctx, tag, opt)
+//		})
+//	}
+//
+//	client.Tags = fakeTags{}
+//
+// Only the methods the code under test calls need to be implemented; the
+// embedded interface, left nil, panics on the others. Note that
+// MediaFetcher, Watcher and the command-line tool call the Context and
+// Iterator methods.
+
+// UsersAPI is implemented by UsersService.
+type UsersAPI interface {
+	Get(userID string) (*User, error)
+	GetContext(ctx context.Context, userID string) (*User, *Response, error)
+	MediaFeed(opt *Parameters) ([]Media, *ResponsePagination, error)
+	MediaFeedContext(ctx context.Context, opt *Parameters) ([]Media, *Response, error)
+	RecentMedia(userID string, opt *Parameters) ([]Media, *ResponsePagination, error)
+	RecentMediaContext(ctx context.Context, userID string, opt *Parameters) ([]Media, *Response, error)
+	LikedMedia(opt *Parameters) ([]Media, *ResponsePagination, error)
+	LikedMediaContext(ctx context.Context, opt *Parameters) ([]Media, *Response, error)
+	Search(q string, opt *Parameters) ([]User, *ResponsePagination, error)
+	SearchContext(ctx context.Context, q string, opt *Parameters) ([]User, *Response, error)
+	RecentMediaIterator(userID string, opt *Parameters) *MediaIterator
+	MediaFeedIterator(opt *Parameters) *MediaIterator
+	LikedMediaIterator(opt *Parameters) *MediaIterator
+}
+
+// RelationshipsAPI is implemented by RelationshipsService.
+type RelationshipsAPI interface {
+	Follows(userID string, opt *Parameters) ([]User, *ResponsePagination, error)
+	FollowsContext(ctx context.Context, userID string, opt *Parameters) ([]User, *Response, error)
+	FollowedBy(userID string, opt *Parameters) ([]User, *ResponsePagination, error)
+	FollowedByContext(ctx context.Context, userID string, opt *Parameters) ([]User, *Response, error)
+	RequestedBy() ([]User, *ResponsePagination, error)
+	RequestedByContext(ctx context.Context) ([]User, *Response, error)
+	Relationship(userID string) (*Relationship, error)
+	RelationshipContext(ctx context.Context, userID string) (*Relationship, *Response, error)
+	Follow(userID string) (*Relationship, error)
+	FollowContext(ctx context.Context, userID string) (*Relationship, *Response, error)
+	Unfollow(userID string) (*Relationship, error)
+	UnfollowContext(ctx context.Context, userID string) (*Relationship, *Response, error)
+	Block(userID string) (*Relationship, error)
+	BlockContext(ctx context.Context, userID string) (*Relationship, *Response, error)
+	Unblock(userID string) (*Relationship, error)
+	UnblockContext(ctx context.Context, userID string) (*Relationship, *Response, error)
+	Approve(userID string) (*Relationship, error)
+	ApproveContext(ctx context.Context, userID string) (*Relationship, *Response, error)
+	Deny(userID string) (*Relationship, error)
+	DenyContext(ctx context.Context, userID string) (*Relationship, *Response, error)
+	FollowsIterator(userID string, opt *Parameters) *UserIterator
+	FollowedByIterator(userID string, opt *Parameters) *UserIterator
+}
+
+// MediaAPI is implemented by MediaService.
+type MediaAPI interface {
+	Get(mediaID string) (*Media, error)
+	GetContext(ctx context.Context, mediaID string) (*Media, *Response, error)
+	GetShortcode(shortcode string) (*Media, error)
+	GetShortcodeContext(ctx context.Context, shortcode string) (*Media, *Response, error)
+	Search(opt *Parameters) ([]Media, *ResponsePagination, error)
+	SearchContext(ctx context.Context, opt *Parameters) ([]Media, *Response, error)
+	Popular() ([]Media, *ResponsePagination, error)
+	PopularContext(ctx context.Context) ([]Media, *Response, error)
+}
+
+// CommentsAPI is implemented by CommentsService.
+type CommentsAPI interface {
+	MediaComments(mediaID string) ([]Comment, error)
+	MediaCommentsContext(ctx context.Context, mediaID string) ([]Comment, *Response, error)
+	Add(mediaID string, text []string) error
+	AddContext(ctx context.Context, mediaID string, text []string) (*Response, error)
+	Delete(mediaID, commentID string) error
+	DeleteContext(ctx context.Context, mediaID, commentID string) (*Response, error)
+}
+
+// LikesAPI is implemented by LikesService.
+type LikesAPI interface {
+	MediaLikes(mediaID string) ([]User, error)
+	MediaLikesContext(ctx context.Context, mediaID string) ([]User, *Response, error)
+	Like(mediaID string) error
+	LikeContext(ctx context.Context, mediaID string) (*Response, error)
+	Unlike(mediaID string) error
+	UnlikeContext(ctx context.Context, mediaID string) (*Response, error)
+}
+
+// TagsAPI is implemented by TagsService.
+type TagsAPI interface {
+	Get(tagName string) (*Tag, error)
+	GetContext(ctx context.Context, tagName string) (*Tag, *Response, error)
+	RecentMedia(tagName string, opt *Parameters) ([]Media, *ResponsePagination, error)
+	RecentMediaContext(ctx context.Context, tagName string, opt *Parameters) ([]Media, *Response, error)
+	Search(q string) ([]Tag, *ResponsePagination, error)
+	SearchContext(ctx context.Context, q string) ([]Tag, *Response, error)
+	RecentMediaIterator(tagName string, opt *Parameters) *MediaIterator
+}
+
+// LocationsAPI is implemented by LocationsService.
+type LocationsAPI interface {
+	Get(locationID string) (*Location, error)
+	GetContext(ctx context.Context, locationID string) (*Location, *Response, error)
+	RecentMedia(locationID string, opt *Parameters) ([]Media, *ResponsePagination, error)
+	RecentMediaContext(ctx context.Context, locationID string, opt *Parameters) ([]Media, *Response, error)
+	Search(lat, lng float64, opt *Parameters) ([]Location, error)
+	SearchContext(ctx context.Context, lat, lng float64, opt *Parameters) ([]Location, *Response, error)
+	RecentMediaIterator(locationID string, opt *Parameters) *MediaIterator
+}
+
+// GeographiesAPI is implemented by GeographiesService.
+type GeographiesAPI interface {
+	RecentMedia(geoID string, opt *Parameters) ([]Media, *ResponsePagination, error)
+	RecentMediaContext(ctx context.Context, geoID string, opt *Parameters) ([]Media, *Response, error)
+	RecentMediaIterator(geoID string, opt *Parameters) *MediaIterator
+}
+
+// RealtimeAPI is implemented by RealtimeService.
+type RealtimeAPI interface {
+	ListSubscriptions() ([]Realtime, error)
+	ListSubscriptionsContext(ctx context.Context) ([]Realtime, *Response, error)
+	Subscribe(sr *SubscriptionRequest) (*Realtime, error)
+	SubscribeContext(ctx context.Context, sr *SubscriptionRequest) (*Realtime, *Response, error)
+	SubscribeToUser(callbackURL, verifyToken string) (*Realtime, error)
+	SubscribeToUserContext(ctx context.Context, callbackURL, verifyToken string) (*Realtime, *Response, error)
+	SubscribeToTag(tag, callbackURL, verifyToken string) (*Realtime, error)
+	SubscribeToTagContext(ctx context.Context, tag, callbackURL, verifyToken string) (*Realtime, *Response, error)
+	SubscribeToLocation(locationId, callbackURL, verifyToken string) (*Realtime, error)
+	SubscribeToLocationContext(ctx context.Context, locationId, callbackURL, verifyToken string) (*Realtime, *Response, error)
+	SubscribeToGeography(lat, lng string, radius int, callbackURL, verifyToken string) (*Realtime, error)
+	SubscribeToGeographyContext(ctx context.Context, lat, lng string, radius int, callbackURL, verifyToken string) (*Realtime, *Response, error)
+	DeleteAllSubscriptions() error
+	DeleteAllSubscriptionsContext(ctx context.Context) (*Response, error)
+	UnsubscribeFrom(sid string) error
+	UnsubscribeFromContext(ctx context.Context, sid string) (*Response, error)
+}
+
+var (
+	_ UsersAPI         = (*UsersService)(nil)
+	_ RelationshipsAPI = (*RelationshipsService)(nil)
+	_ MediaAPI         = (*MediaService)(nil)
+	_ CommentsAPI      = (*CommentsService)(nil)
+	_ LikesAPI         = (*LikesService)(nil)
+	_ TagsAPI          = (*TagsService)(nil)
+	_ LocationsAPI     = (*LocationsService)(nil)
+	_ GeographiesAPI   = (*GeographiesService)(nil)
+	_ RealtimeAPI      = (*RealtimeService)(nil)
+)
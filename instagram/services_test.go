@@ -0,0 +1,62 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// mockTags serves the recent media of a tag from memory.
+type mockTags struct {
+	TagsAPI
+	media []Media
+}
+
+func (m *mockTags) RecentMediaContext(ctx context.Context, tagName string, opt *Parameters) ([]Media, *Response, error) {
+	var media []Media
+	for _, md := range m.media {
+		if opt.MinTagID == "" || md.ID > opt.MinTagID {
+			media = append(media, md)
+		}
+	}
+	pagination := new(ResponsePagination)
+	if len(media) > 0 {
+		pagination.MinTagID = media[0].ID
+	}
+	return media, &Response{Pagination: pagination}, nil
+}
+
+func (m *mockTags) RecentMediaIterator(tagName string, opt *Parameters) *MediaIterator {
+	return NewMediaIterator(opt, func(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
+		return m.RecentMediaContext(ctx, tagName, opt)
+	})
+}
+
+func TestClient_mockService(t *testing.T) {
+	client := NewClient(nil)
+	mock := &mockTags{media: []Media{{ID: "2"}, {ID: "1"}}}
+	client.Tags = mock
+
+	f := &MediaFetcher{Client: client}
+	media, err := f.Fetch(context.Background(), ObjectTag, "go")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if got := ids(media); !reflect.DeepEqual(got, []string{"2", "1"}) {
+		t.Errorf("Fetch returned %v, want [2 1]", got)
+	}
+
+	mock.media = append([]Media{{ID: "3"}}, mock.media...)
+	media, err = f.Fetch(context.Background(), ObjectTag, "go")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if got := ids(media); !reflect.DeepEqual(got, []string{"3"}) {
+		t.Errorf("Fetch returned %v, want [3]", got)
+	}
+}
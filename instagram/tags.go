@@ -6,6 +6,7 @@
 package instagram
 
 import (
+	"context"
 	// "errors"
 	"fmt"
 	"net/url"
@@ -31,21 +32,39 @@ type Tag struct {
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/tags/#get_tags
 func (s *TagsService) Get(tagName string) (*Tag, error) {
+	tag, _, err := s.GetContext(context.Background(), tagName)
+	return tag, err
+}
+
+// GetContext is like Get but stops when ctx is done and returns the Response.
+func (s *TagsService) GetContext(ctx context.Context, tagName string) (*Tag, *Response, error) {
 	u := fmt.Sprintf("tags/%v", tagName)
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	tag := new(Tag)
-	_, err = s.client.Do(req, tag)
-	return tag, err
+	resp, err := s.client.DoContext(ctx, req, tag)
+	return tag, resp, err
 }
 
-// RecentMedia Get a list of recently tagged media.
+// RecentMedia Get a list of recently tagged media. Pages are selected with
+// MinTagID and MaxTagID, not MinID and MaxID; the next page starts at the
+// NextMaxTagID of the pagination.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/tags/#get_tags_media_recent
 func (s *TagsService) RecentMedia(tagName string, opt *Parameters) ([]Media, *ResponsePagination, error) {
+	media, resp, err := s.RecentMediaContext(context.Background(), tagName, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, resp.Pagination, nil
+}
+
+// RecentMediaContext is like RecentMedia but stops when ctx is done and returns
+// the Response.
+func (s *TagsService) RecentMediaContext(ctx context.Context, tagName string, opt *Parameters) ([]Media, *Response, error) {
 	valid, err := validTagName(tagName)
 	if err != nil {
 		return nil, nil, err
@@ -56,7 +75,7 @@ func (s *TagsService) RecentMedia(tagName string, opt *Parameters) ([]Media, *Re
 		//but it's not clearly defined (as far as I can tell) in the Instagram spec that it *couldn't* give a result
 		//In future, this might change to give an error, though
 		//return nil, nil, errors.New(`go-instagram Tag.RecentMedia error: Tag names must contain only alphabetical and numerical characters.`)
-		return []Media{}, &ResponsePagination{}, nil
+		return []Media{}, &Response{Pagination: &ResponsePagination{}}, nil
 	}
 
 	u := fmt.Sprintf("tags/%v/media/recent", tagName)
@@ -71,18 +90,24 @@ func (s *TagsService) RecentMedia(tagName string, opt *Parameters) ([]Media, *Re
 		if opt.MaxID != "" {
 			params.Add("max_id", opt.MaxID)
 		}
+		if opt.MinTagID != "" {
+			params.Add("min_tag_id", opt.MinTagID)
+		}
+		if opt.MaxTagID != "" {
+			params.Add("max_tag_id", opt.MaxTagID)
+		}
 		u += "?" + params.Encode()
 	}
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	media := new([]Media)
 
-	_, err = s.client.Do(req, media)
+	resp, err := s.client.DoContext(ctx, req, media)
 	if err != nil {
-		return nil, nil, err
+		return nil, resp, err
 	}
 	/*
 		if err != nil {
@@ -94,37 +119,37 @@ func (s *TagsService) RecentMedia(tagName string, opt *Parameters) ([]Media, *Re
 		}
 	*/
 
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
-	}
-
-	return *media, page, err
+	return *media, resp, err
 }
 
 // Search for tags by name.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/tags/#get_tags_search
 func (s *TagsService) Search(q string) ([]Tag, *ResponsePagination, error) {
-	u := "tags/search?q=" + q
-	req, err := s.client.NewRequest("GET", u, "")
+	tags, resp, err := s.SearchContext(context.Background(), q)
 	if err != nil {
 		return nil, nil, err
 	}
+	return tags, resp.Pagination, nil
+}
 
-	tags := new([]Tag)
-
-	_, err = s.client.Do(req, tags)
+// SearchContext is like Search but stops when ctx is done and returns the
+// Response.
+func (s *TagsService) SearchContext(ctx context.Context, q string) ([]Tag, *Response, error) {
+	u := "tags/search?q=" + q
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
+	tags := new([]Tag)
+
+	resp, err := s.client.DoContext(ctx, req, tags)
+	if err != nil {
+		return nil, resp, err
 	}
 
-	return *tags, page, err
+	return *tags, resp, err
 }
 
 // Strip out things we know Instagram won't accept. For example, hyphens.
@@ -39,17 +39,21 @@ func TestTagsService_RecentMedia(t *testing.T) {
 	mux.HandleFunc("/tags/tagname/media/recent", func(w http.ResponseWriter, r *http.Request) {
 		testMethod(t, r, "GET")
 		testFormValues(t, r, values{
-			"min_id": "1",
-			"max_id": "1",
-			"count":  "1",
+			"min_id":     "1",
+			"max_id":     "1",
+			"min_tag_id": "2",
+			"max_tag_id": "3",
+			"count":      "1",
 		})
 		fmt.Fprint(w, `{"data": [{"id":"1"}]}`)
 	})
 
 	opt := &Parameters{
-		MinID: "1",
-		MaxID: "1",
-		Count: 1,
+		MinID:    "1",
+		MaxID:    "1",
+		MinTagID: "2",
+		MaxTagID: "3",
+		Count:    1,
 	}
 	media, _, err := client.Tags.RecentMedia("tagname", opt)
 	if err != nil {
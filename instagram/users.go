@@ -6,6 +6,7 @@
 package instagram
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -42,26 +43,42 @@ type UserCount struct {
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/users/#get_users
 func (s *UsersService) Get(userID string) (*User, error) {
+	user, _, err := s.GetContext(context.Background(), userID)
+	return user, err
+}
+
+// GetContext is like Get but stops when ctx is done and returns the Response.
+func (s *UsersService) GetContext(ctx context.Context, userID string) (*User, *Response, error) {
 	var u string
 	if userID != "" {
 		u = fmt.Sprintf("users/%v", userID)
 	} else {
 		u = "users/self"
 	}
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	user := new(User)
-	_, err = s.client.Do(req, user)
-	return user, err
+	resp, err := s.client.DoContext(ctx, req, user)
+	return user, resp, err
 }
 
 // MediaFeed gets authenticated user's feed.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/users/#get_users_feed
 func (s *UsersService) MediaFeed(opt *Parameters) ([]Media, *ResponsePagination, error) {
+	media, resp, err := s.MediaFeedContext(context.Background(), opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, resp.Pagination, nil
+}
+
+// MediaFeedContext is like MediaFeed but stops when ctx is done and returns the
+// Response.
+func (s *UsersService) MediaFeedContext(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
 	u := "users/self/feed"
 	if opt != nil {
 		params := url.Values{}
@@ -77,30 +94,35 @@ func (s *UsersService) MediaFeed(opt *Parameters) ([]Media, *ResponsePagination,
 		u += "?" + params.Encode()
 	}
 
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	media := new([]Media)
 
-	_, err = s.client.Do(req, media)
+	resp, err := s.client.DoContext(ctx, req, media)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
+		return nil, resp, err
 	}
 
-	return *media, page, err
+	return *media, resp, err
 }
 
 // RecentMedia gets the most recent media published by a user.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/users/#get_users_media_recent
 func (s *UsersService) RecentMedia(userID string, opt *Parameters) ([]Media, *ResponsePagination, error) {
+	media, resp, err := s.RecentMediaContext(context.Background(), userID, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, resp.Pagination, nil
+}
+
+// RecentMediaContext is like RecentMedia but stops when ctx is done and returns
+// the Response.
+func (s *UsersService) RecentMediaContext(ctx context.Context, userID string, opt *Parameters) ([]Media, *Response, error) {
 	var u string
 	if userID != "" {
 		u = fmt.Sprintf("users/%v/media/recent", userID)
@@ -127,30 +149,35 @@ func (s *UsersService) RecentMedia(userID string, opt *Parameters) ([]Media, *Re
 		u += "?" + params.Encode()
 	}
 
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	media := new([]Media)
 
-	_, err = s.client.Do(req, media)
+	resp, err := s.client.DoContext(ctx, req, media)
 	if err != nil {
-		return nil, nil, err
+		return nil, resp, err
 	}
 
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
-	}
-
-	return *media, page, err
+	return *media, resp, err
 }
 
 // LikedMedia gets authenticated user's list of media they've liked.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/users/#get_users_feed_liked
 func (s *UsersService) LikedMedia(opt *Parameters) ([]Media, *ResponsePagination, error) {
+	media, resp, err := s.LikedMediaContext(context.Background(), opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, resp.Pagination, nil
+}
+
+// LikedMediaContext is like LikedMedia but stops when ctx is done and returns
+// the Response.
+func (s *UsersService) LikedMediaContext(ctx context.Context, opt *Parameters) ([]Media, *Response, error) {
 	u := "users/self/media/liked"
 	if opt != nil {
 		params := url.Values{}
@@ -163,30 +190,35 @@ func (s *UsersService) LikedMedia(opt *Parameters) ([]Media, *ResponsePagination
 		u += "?" + params.Encode()
 	}
 
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	media := new([]Media)
 
-	_, err = s.client.Do(req, media)
+	resp, err := s.client.DoContext(ctx, req, media)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
+		return nil, resp, err
 	}
 
-	return *media, page, err
+	return *media, resp, err
 }
 
 // Search for a user by name.
 //
 // Instagram API docs: http://instagram.com/developer/endpoints/users/#get_users_search
 func (s *UsersService) Search(q string, opt *Parameters) ([]User, *ResponsePagination, error) {
+	users, resp, err := s.SearchContext(context.Background(), q, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, resp.Pagination, nil
+}
+
+// SearchContext is like Search but stops when ctx is done and returns the
+// Response.
+func (s *UsersService) SearchContext(ctx context.Context, q string, opt *Parameters) ([]User, *Response, error) {
 	u := "users/search"
 	params := url.Values{}
 	params.Add("q", q)
@@ -197,22 +229,17 @@ func (s *UsersService) Search(q string, opt *Parameters) ([]User, *ResponsePagin
 	}
 	u += "?" + params.Encode()
 
-	req, err := s.client.NewRequest("GET", u, "")
+	req, err := s.client.NewRequestWithContext(ctx, "GET", u, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
 	users := new([]User)
 
-	_, err = s.client.Do(req, users)
+	resp, err := s.client.DoContext(ctx, req, users)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	page := new(ResponsePagination)
-	if s.client.Response.Pagination != nil {
-		page = s.client.Response.Pagination
+		return nil, resp, err
 	}
 
-	return *users, page, err
+	return *users, resp, err
 }
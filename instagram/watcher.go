@@ -0,0 +1,157 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWatchInterval is the shortest delay between two polls of a Watcher.
+const DefaultWatchInterval = time.Minute
+
+// WatchEvent is a new media found by a Watcher.
+type WatchEvent struct {
+	// Object and ObjectID identify what the media was found for, e.g. "tag"
+	// and a tag name.
+	Object   string
+	ObjectID string
+
+	Media Media
+}
+
+// Watcher polls the recent media of tags, locations and users, as a fallback
+// for realtime subscriptions where Instagram cannot reach a callback URL. Only
+// the media not seen before are emitted; checkpoints are kept as by
+// MediaFetcher.
+//
+// The delay between polls is at least Interval, and grows when the remaining
+// rate limit would not allow polling every target at that pace for the rest
+// of the hour.
+type Watcher struct {
+	Client *Client
+
+	// Tags, Locations and Users list the tag names, location IDs and user IDs
+	// to watch.
+	Tags      []string
+	Locations []string
+	Users     []string
+
+	// Store holds the checkpoints. An in-memory store is used when nil.
+	Store CheckpointStore
+
+	// Interval is the shortest delay between two polls. DefaultWatchInterval
+	// is used when zero.
+	Interval time.Duration
+
+	// Since, if set, drops the media created before it, e.g. those on the
+	// first page of a target seen for the first time. It is passed as
+	// MinTimestamp to the users and locations without checkpoint.
+	Since time.Time
+
+	// MaxPages limits the number of pages fetched per target and poll. Zero
+	// means no limit.
+	MaxPages int
+
+	// OnError, if set, is called when polling a target fails. The other
+	// targets are polled anyway and the target is retried on the next poll.
+	OnError func(object, objectID string, err error)
+}
+
+// Watch starts polling and returns the channel the new media are sent to. It
+// is closed once ctx is done and the current poll has returned.
+func (w *Watcher) Watch(ctx context.Context) <-chan WatchEvent {
+	ch := make(chan WatchEvent)
+	go w.loop(ctx, ch)
+	return ch
+}
+
+func (w *Watcher) loop(ctx context.Context, ch chan<- WatchEvent) {
+	defer close(ch)
+
+	f := &MediaFetcher{Client: w.Client, Store: w.Store, MaxPages: w.MaxPages}
+	for {
+		rl, ok := w.poll(ctx, f, ch)
+		if ctx.Err() != nil {
+			return
+		}
+		if sleep(ctx, w.next(rl, ok)) != nil {
+			return
+		}
+	}
+}
+
+// poll fetches every target once and sends its new media, oldest first. It
+// returns the lowest rate limit reported, if any.
+func (w *Watcher) poll(ctx context.Context, f *MediaFetcher, ch chan<- WatchEvent) (rl Ratelimit, ok bool) {
+	targets := []struct {
+		object string
+		ids    []string
+	}{
+		{ObjectTag, w.Tags},
+		{ObjectLocation, w.Locations},
+		{ObjectUser, w.Users},
+	}
+
+	for _, t := range targets {
+		for _, id := range t.ids {
+			media, resp, err := f.fetch(ctx, t.object, id, w.Since)
+			if resp != nil {
+				if r, err := resp.GetRatelimit(); err == nil && r.Limit > 0 && (!ok || r.Remaining < rl.Remaining) {
+					rl, ok = r, true
+				}
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return rl, ok
+				}
+				if w.OnError != nil {
+					w.OnError(t.object, id, err)
+				}
+				continue
+			}
+
+			for i := len(media) - 1; i >= 0; i-- {
+				if !w.Since.IsZero() && media[i].CreatedTime < w.Since.Unix() {
+					continue
+				}
+				select {
+				case ch <- WatchEvent{Object: t.object, ObjectID: id, Media: media[i]}:
+				case <-ctx.Done():
+					return rl, ok
+				}
+			}
+		}
+	}
+
+	return rl, ok
+}
+
+// targets returns the number of targets watched.
+func (w *Watcher) targets() int {
+	return len(w.Tags) + len(w.Locations) + len(w.Users)
+}
+
+// next returns the delay before the next poll, given the lowest rate limit
+// reported by the last one.
+func (w *Watcher) next(rl Ratelimit, ok bool) time.Duration {
+	d := w.Interval
+	if d <= 0 {
+		d = DefaultWatchInterval
+	}
+	if !ok {
+		return d
+	}
+	if rl.Remaining <= 0 {
+		return DefaultRatelimitWindow
+	}
+
+	// Spread the remaining calls, one per target and poll, over an hour.
+	if spread := DefaultRatelimitWindow * time.Duration(w.targets()) / time.Duration(rl.Remaining); spread > d {
+		d = spread
+	}
+	return d
+}
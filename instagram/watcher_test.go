@@ -0,0 +1,122 @@
+// Copyright 2013 The go-instagram AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instagram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Watch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/tags/t/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("min_tag_id") {
+		case "":
+			fmt.Fprint(w, `{"data": [{"id":"2"}, {"id":"1"}], "pagination": {"min_tag_id": "20"}}`)
+		case "20":
+			fmt.Fprint(w, `{"data": [{"id":"3"}], "pagination": {"min_tag_id": "30"}}`)
+		default:
+			fmt.Fprint(w, `{"data": []}`)
+		}
+	})
+	mux.HandleFunc("/users/u/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"meta": {"code": 400, "error_type": "APINotAllowedError", "error_message": "private"}}`)
+	})
+
+	var failed []string
+	w := &Watcher{
+		Client:   client,
+		Tags:     []string{"t"},
+		Users:    []string{"u"},
+		Interval: time.Millisecond,
+		OnError: func(object, objectID string, err error) {
+			failed = append(failed, object+":"+objectID)
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := w.Watch(ctx)
+
+	var got []string
+	for e := range ch {
+		if e.Object != ObjectTag || e.ObjectID != "t" {
+			t.Errorf("Watch emitted media for %s %s", e.Object, e.ObjectID)
+		}
+		got = append(got, e.Media.ID)
+		if len(got) == 3 {
+			cancel()
+		}
+	}
+
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Watch emitted %v, want %v", got, want)
+	}
+	if len(failed) == 0 || failed[0] != "user:u" {
+		t.Errorf("OnError called for %v, want user:u", failed)
+	}
+}
+
+func TestWatcher_Watch_since(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var queries []string
+	mux.HandleFunc("/users/u/media/recent", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.FormValue("min_id") == "" {
+			fmt.Fprint(w, `{"data": [{"id":"2", "created_time":"1000"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id":"3", "created_time":"2000"}, {"id":"2", "created_time":"1000"}]}`)
+	})
+
+	w := &Watcher{Client: client, Users: []string{"u"}, Interval: time.Millisecond, Since: time.Unix(900, 0)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got []string
+	for e := range w.Watch(ctx) {
+		got = append(got, e.Media.ID)
+		if len(got) == 2 {
+			cancel()
+		}
+	}
+
+	if want := []string{"2", "3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Watch emitted %v, want %v", got, want)
+	}
+	if want := []string{"min_timestamp=900", "min_id=2"}; len(queries) < 2 || !reflect.DeepEqual(queries[:2], want) {
+		t.Errorf("Watch requested %q, want %q first", queries, want)
+	}
+}
+
+func TestWatcher_next(t *testing.T) {
+	w := &Watcher{Tags: []string{"a"}, Locations: []string{"1"}}
+
+	tests := []struct {
+		rl   Ratelimit
+		ok   bool
+		want time.Duration
+	}{
+		{Ratelimit{}, false, DefaultWatchInterval},
+		{Ratelimit{Limit: 5000, Remaining: 4000}, true, DefaultWatchInterval},
+		{Ratelimit{Limit: 5000, Remaining: 60}, true, 2 * time.Minute},
+		{Ratelimit{Limit: 5000, Remaining: 0}, true, DefaultRatelimitWindow},
+	}
+	for _, tt := range tests {
+		if got := w.next(tt.rl, tt.ok); got != tt.want {
+			t.Errorf("next(%+v) = %v, want %v", tt.rl, got, tt.want)
+		}
+	}
+}